@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openstreetmap-si/GursAddressesForOSM/gurs"
+	"github.com/paulmach/osm"
+)
+
+// runConflate conflates each converted GeoJSON file with a local OSM extract
+func runConflate(args []string) error {
+	defaults := gurs.DefaultConflationOptions()
+	flags := flag.NewFlagSet("conflate", flag.ExitOnError)
+	extractFileName := flags.String("extract", "", "Local OSM extract (.osm or .osm.pbf) covering the converted addresses")
+	geoJSONPattern := flags.String("geojson", "data/slovenia/*/*-housenumbers-gurs.geojson", "Glob pattern of converted GeoJSON files, <name>-gurs.geojson is conflated to <name>.osc, <name>-preview.geojson and <name>-conflate-log.txt")
+	profileFileName := flags.String("profile", gurs.DefaultTaggingProfileFile, "JSON tagging profile the GeoJSON files were converted with")
+	masterTags := flags.String("master-tags", "", "Comma separated tags replaced on matched OSM objects, others are left as they are; master_tags of the profile if empty ("+strings.Join(defaults.MasterTags, ",")+" by default)")
+	maxDistance := flags.Float64("max-distance", defaults.MaxDistance, "Maximum distance in meters between a GURS address and the matched OSM object centroid")
+	duplicateDistance := flags.Float64("duplicate-distance", defaults.DuplicateDistance, "GURS addresses with the same tags closer than this (meters) are duplicates, only the first is added when unmatched, negative to disable")
+	deleteUnmatched := flags.Bool("delete-unmatched", defaults.DeleteUnmatched, "Delete or remove address tags from unmatched OSM objects around the dataset")
+	attachToBuildings := flags.Bool("buildings", defaults.AttachToBuildings, "Add unmatched addresses alone in a building outline without address tags to the building instead of a new node")
+	generator := flags.String("osm-generator", defaults.Generator, "generator attribute of the .osc files")
+	refsReportFileName := flags.String("refs-report", "data/conflate-refs-report.csv", "CSV report of ref:gurs:hs_mid values in OSM no longer in GURS (stale) or on more than one object (duplicate)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *extractFileName == "" {
+		flags.Usage()
+		return errors.New("conflate: -extract is required")
+	}
+
+	profile, err := gurs.ReadTaggingProfile(*profileFileName)
+	if err != nil {
+		return err
+	}
+	master := profile.MasterKeys()
+	if *masterTags != "" {
+		master = strings.Split(*masterTags, ",")
+	}
+
+	geoJSONFileNames, err := filepath.Glob(*geoJSONPattern)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Reading %s...", *extractFileName)
+	keep := profile.IsAddressed
+	if *attachToBuildings {
+		keep = func(tags osm.Tags) bool {
+			return profile.IsAddressed(tags) || gurs.IsBuilding(tags)
+		}
+	}
+	extract, err := gurs.ReadOsmExtract(*extractFileName, keep)
+	if err != nil {
+		return err
+	}
+	log.Printf("Read %d OSM objects, %d incomplete ways and relations skipped", len(extract.Objects), extract.Incomplete)
+
+	conflator := gurs.NewConflator(extract, gurs.ConflationOptions{
+		MaxDistance:       *maxDistance,
+		DuplicateDistance: *duplicateDistance,
+		DeleteUnmatched:   *deleteUnmatched,
+		AttachToBuildings: *attachToBuildings,
+		MasterTags:        master,
+		Profile:           profile,
+		Generator:         *generator,
+	})
+	// unmatched objects are only decided once no other file can match them
+	for _, geoJSONFileName := range geoJSONFileNames {
+		featureCollection, err := gurs.ReadGeoJSON(geoJSONFileName)
+		if err != nil {
+			return err
+		}
+		conflator.AddDataset(featureCollection)
+	}
+	for _, geoJSONFileName := range geoJSONFileNames {
+		featureCollection, err := gurs.ReadGeoJSON(geoJSONFileName)
+		if err != nil {
+			return err
+		}
+
+		conflation := conflator.Conflate(featureCollection)
+		baseName := strings.TrimSuffix(geoJSONFileName, "-gurs.geojson")
+		if err := gurs.WriteOsmChange(conflation.OsmChange(), baseName+".osc"); err != nil {
+			return err
+		}
+		if err := gurs.WritePreview(conflation.Preview(), baseName+"-preview.geojson"); err != nil {
+			return err
+		}
+		summary := conflation.Summary()
+		if err := os.WriteFile(baseName+"-conflate-log.txt", []byte(summary+"\n"), 0644); err != nil {
+			return err
+		}
+		log.Printf("***** Conflated: %s *****\n%s", baseName, summary)
+	}
+
+	log.Printf("%d OSM objects with %s no longer in GURS, %d refs on more than one OSM object",
+		len(conflator.StaleRefs()), profile.Keys.Ref, len(conflator.DuplicateRefs()))
+	if *refsReportFileName == "" {
+		return nil
+	}
+	return conflator.WriteRefReport(*refsReportFileName)
+}
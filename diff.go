@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+
+	"github.com/openstreetmap-si/GursAddressesForOSM/gurs"
+)
+
+// runDiff compares two GURS releases by ref:gurs:hs_mid
+func runDiff(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	oldDir := flags.String("old", "", "Directory with the older release (RPE_*.ZIP archives or extracted shapefiles)")
+	newDir := flags.String("new", "data/downloaded/", "Directory with the newer release (RPE_*.ZIP archives or extracted shapefiles)")
+	overrides := flags.String("overrides", "overrides/", "Directory with <COLUMN>.csv overrides of abbreviated names")
+	oldOverrides := flags.String("old-overrides", "", "Directory with the overrides the older release was converted with, -overrides if empty (overrides added since then are listed as retagged)")
+	outputGeoJSONFileName := flags.String("out", "data/diff.geojson", "Output GeoJSON file with added, removed, moved and retagged addresses")
+	profileFileName := flags.String("profile", gurs.DefaultTaggingProfileFile, "JSON tagging profile with the OSM keys of the GURS values")
+	summaryFileName := flags.String("summary", "data/diff-summary.csv", "CSV summary of changes per municipality and settlement")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *oldDir == "" {
+		flags.Usage()
+		return errors.New("diff: -old is required")
+	}
+
+	profile, err := gurs.ReadTaggingProfile(*profileFileName)
+	if err != nil {
+		return err
+	}
+	if *oldOverrides == "" {
+		*oldOverrides = *overrides
+	}
+	before, err := gurs.ReadSnapshot(*oldDir, *oldOverrides, profile)
+	if err != nil {
+		return err
+	}
+	after, err := gurs.ReadSnapshot(*newDir, *overrides, profile)
+	if err != nil {
+		return err
+	}
+
+	changes := gurs.Diff(before, after, profile)
+	summaries := gurs.SummarizeDiff(changes)
+	counts := make(map[gurs.ChangeType]int)
+	for _, change := range changes {
+		counts[change.Type]++
+	}
+	log.Printf("%d added, %d removed, %d moved and %d retagged addresses in %d settlements",
+		counts[gurs.ChangeAdded], counts[gurs.ChangeRemoved], counts[gurs.ChangeMoved], counts[gurs.ChangeRetagged], len(summaries))
+
+	if err := gurs.WriteDiffGeoJSON(changes, *outputGeoJSONFileName, profile); err != nil {
+		return err
+	}
+	log.Printf("Saved %d changes to %s", len(changes), *outputGeoJSONFileName)
+	return gurs.WriteDiffSummaryCSV(summaries, *summaryFileName)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openstreetmap-si/GursAddressesForOSM/gurs"
+	"golang.org/x/term"
+)
+
+// defaultNetrc is $NETRC or ~/.netrc
+func defaultNetrc() string {
+	if netrc := os.Getenv("NETRC"); netrc != "" {
+		return netrc
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+// promptCredentials asks for missing credentials on the terminal, the password is not echoed
+func promptCredentials(username string) (gurs.Credentials, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return gurs.Credentials{}, fmt.Errorf("no credentials: set %s and %s, add %s to .netrc or use -credentials-command",
+			gurs.UsernameEnv, gurs.PasswordEnv, gurs.PortalMachine)
+	}
+	if username == "" {
+		fmt.Fprintf(os.Stderr, "Username for %s: ", gurs.DefaultPortalURL)
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return gurs.Credentials{}, err
+		}
+		username = strings.TrimSpace(line)
+	}
+	fmt.Fprintf(os.Stderr, "Password for %s: ", username)
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return gurs.Credentials{}, err
+	}
+	if username == "" || len(password) == 0 {
+		return gurs.Credentials{}, errors.New("no credentials entered")
+	}
+	return gurs.Credentials{Username: username, Password: string(password)}, nil
+}
+
+// readCredentials takes the first of: environment variables, credentials command, .netrc file, terminal prompt
+func readCredentials(command, netrc, username string) (gurs.Credentials, error) {
+	if credentials, found := gurs.CredentialsFromEnv(); found {
+		log.Printf("Using credentials of %s from %s and %s", credentials.Username, gurs.UsernameEnv, gurs.PasswordEnv)
+		return credentials, nil
+	}
+	if command != "" {
+		credentials, err := gurs.CredentialsFromCommand(command, username)
+		if err == nil {
+			log.Printf("Using credentials of %s from the credentials command", credentials.Username)
+		}
+		return credentials, err
+	}
+	if netrc != "" {
+		credentials, found, err := gurs.CredentialsFromNetrc(netrc, gurs.PortalMachine)
+		if err != nil {
+			return gurs.Credentials{}, err
+		}
+		if found {
+			log.Printf("Using credentials of %s from %s", credentials.Username, netrc)
+			return credentials, nil
+		}
+	}
+	return promptCredentials(username)
+}
+
+// runDownload fetches the RPE archives from the GURS portal
+func runDownload(args []string) error {
+	flags := flag.NewFlagSet("download", flag.ExitOnError)
+	dir := flags.String("dir", "data/downloaded/", "Directory to save RPE_*.ZIP archives to")
+	baseURL := flags.String("url", gurs.DefaultPortalURL, "Portal URL with login.html and download-file.html")
+	caFile := flags.String("ca", "sigov-ca2.pem", "PEM file with the only CA certificates trusted for the portal")
+	netrc := flags.String("netrc", defaultNetrc(), "File with a \"machine "+gurs.PortalMachine+" login ... password ...\" line, must not be accessible by other users")
+	command := flags.String("credentials-command", "", "Command printing the password in the first line and optionally \"login: <username>\", eg. \"pass show "+gurs.PortalMachine+"\"")
+	username := flags.String("user", os.Getenv(gurs.UsernameEnv), "Username for -credentials-command output or the prompt without one")
+	maxAge := flags.Duration("max-age", gurs.DefaultMaxAge, "Download only if an archive is missing or older than this")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	client, err := gurs.NewPortalClient(*caFile)
+	if err != nil {
+		return err
+	}
+	downloader := gurs.NewDownloader(client, gurs.Credentials{})
+	downloader.BaseURL = *baseURL
+	downloader.MaxAge = *maxAge
+	if len(downloader.Stale(*dir)) > 0 {
+		if downloader.Credentials, err = readCredentials(*command, *netrc, *username); err != nil {
+			return err
+		}
+	}
+	return downloader.Download(*dir)
+}
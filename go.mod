@@ -5,5 +5,15 @@ go 1.21
 require (
 	github.com/jonas-p/go-shp v0.1.1
 	github.com/paulmach/go.geojson v1.5.0
+	github.com/paulmach/osm v0.8.0
+	golang.org/x/term v0.21.0
 	golang.org/x/text v0.16.0
 )
+
+require (
+	github.com/datadog/czlib v0.0.0-20160811164712-4bc9a24e37f2 // indirect
+	github.com/paulmach/orb v0.1.3 // indirect
+	github.com/paulmach/protoscan v0.2.1 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+	google.golang.org/protobuf v1.27.1 // indirect
+)
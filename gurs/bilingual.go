@@ -0,0 +1,233 @@
+package gurs
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BilingualAreasFile in the overrides directory declares the areas with official minority language names
+const BilingualAreasFile = "bilingualAreas.csv"
+
+// problems of the bilingual areas report
+const (
+	bilingualOutsideArea = "outside_area"
+	bilingualUnusedArea  = "no_bilingual_names"
+	bilingualPostParts   = "post_name_parts"
+	bilingualPostHyphen  = "unsplit_post_name"
+)
+
+// BilingualArea is a municipality, or a settlement in it, with official names also in Italian or Hungarian
+type BilingualArea struct {
+	// tag language postfix without the colon, "it" or "hu"
+	Language string
+	// OB_MID or name of the municipality
+	Municipality string
+	// NA_MID or name of the settlement, empty for the whole municipality
+	Settlement string
+	Line       int
+	// number of bilingual names of HS records in the area
+	names int
+}
+
+// matches compares the MID and name of a lookup row to a table entry, bilingual names also by the Slovenian part
+func areaMatches(entry, mid, name string) bool {
+	if entry == mid || entry == name {
+		return true
+	}
+	for _, separator := range []string{bilingualSeparator, "/", " - "} {
+		if slovenian, _, found := strings.Cut(name, separator); found && strings.TrimSpace(slovenian) == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// bilingualAreas finds the area of HS records by municipality and settlement
+type bilingualAreas struct {
+	areas []*BilingualArea
+	// by OB_MID and NA_MID, nil if not in any area
+	cache map[[2]string]*BilingualArea
+	// reported names by problem, column and MID
+	checks map[[3]string]*BilingualCheck
+}
+
+// readBilingualAreas reads language,municipality,settlement rows, lines starting with # are comments;
+// a missing file declares no areas
+func readBilingualAreas(filename string) (*bilingualAreas, error) {
+	result := &bilingualAreas{cache: make(map[[2]string]*BilingualArea), checks: make(map[[3]string]*BilingualCheck)}
+
+	csvFile, err := os.Open(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer csvFile.Close()
+
+	reader := csv.NewReader(bufio.NewReader(csvFile))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 3
+	seen := make(map[[2]string]int)
+	var errs []error
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading bilingual areas %s: %w", filename, err)
+		}
+		line, _ := reader.FieldPos(0)
+		if first && record[0] == "language" {
+			continue
+		}
+
+		area := &BilingualArea{Language: record[0], Municipality: strings.TrimSpace(record[1]), Settlement: strings.TrimSpace(record[2]), Line: line}
+		switch {
+		case area.Language != strings.TrimPrefix(tagLangPostfixItalian, ":") && area.Language != strings.TrimPrefix(tagLangPostfixHungarian, ":"):
+			errs = append(errs, fmt.Errorf("%s:%d: unknown language %q, should be it or hu", filename, line, area.Language))
+			continue
+		case area.Municipality == "":
+			errs = append(errs, fmt.Errorf("%s:%d: no municipality", filename, line))
+			continue
+		}
+		key := [2]string{area.Municipality, area.Settlement}
+		if previous, found := seen[key]; found {
+			errs = append(errs, fmt.Errorf("%s:%d: duplicate of line %d", filename, line, previous))
+			continue
+		}
+		seen[key] = line
+		result.areas = append(result.areas, area)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// area returns the declared area of the municipality and settlement, settlements take precedence, nil if none
+func (b *bilingualAreas) area(obMid, obName, naMid, naName string) *BilingualArea {
+	key := [2]string{obMid, naMid}
+	if area, found := b.cache[key]; found {
+		return area
+	}
+	var result *BilingualArea
+	for _, area := range b.areas {
+		if !areaMatches(area.Municipality, obMid, obName) {
+			continue
+		}
+		if area.Settlement == "" && result == nil {
+			result = area
+		} else if area.Settlement != "" && areaMatches(area.Settlement, naMid, naName) {
+			result = area
+			break
+		}
+	}
+	b.cache[key] = result
+	return result
+}
+
+// declares returns true if a table entry is the whole municipality or the settlement with the name
+func (b *bilingualAreas) declares(name string) bool {
+	for _, area := range b.areas {
+		if area.Settlement == name || area.Settlement == "" && area.Municipality == name {
+			return true
+		}
+	}
+	return false
+}
+
+// BilingualCheck is a problem of the bilingual areas table or of a bilingual name found in the GURS data
+type BilingualCheck struct {
+	// outside_area: bilingual name of a record outside of all declared areas,
+	// no_bilingual_names: declared area without any bilingual names,
+	// post_name_parts: post name with more than two names or an empty one, kept whole,
+	// unsplit_post_name: hyphenated post name not recognised as bilingual used in a declared area
+	Problem string
+	// UL_DJ, NA_DJ or PT_UIME with the MID and the (minority language) name
+	Column, Mid, Name string
+	// names of the first record with the name, the table entry for no_bilingual_names
+	Municipality, Settlement string
+	Records                  int
+}
+
+// report counts a record with a problem of the name
+func (b *bilingualAreas) report(problem, column, mid, name, obName, naName string) {
+	key := [3]string{problem, column, mid}
+	check, found := b.checks[key]
+	if !found {
+		check = &BilingualCheck{Problem: problem, Column: column, Mid: mid, Name: name, Municipality: obName, Settlement: naName}
+		b.checks[key] = check
+	}
+	check.Records++
+}
+
+// bilingualTag returns the tag with the language postfix of the record area for a bilingual name,
+// outside of declared areas it is guessed from the longitude and the name is reported
+func (c *Converter) bilingualTag(prefix string, area *BilingualArea, column, mid, name, obName, naName string, lon float64) string {
+	if area != nil {
+		area.names++
+		return prefix + ":" + area.Language
+	}
+	c.bilingual.report(bilingualOutsideArea, column, mid, name, obName, naName)
+	return ApplyTagLanguagePostfix(prefix, lon)
+}
+
+// BilingualChecks returns bilingual names of the last Convert call outside of the declared areas
+// and odd post names (by problem, column and MID) and declared areas without bilingual names
+func (c *Converter) BilingualChecks() []BilingualCheck {
+	var checks []BilingualCheck
+	for _, check := range c.bilingual.checks {
+		checks = append(checks, *check)
+	}
+	sort.Slice(checks, func(i, j int) bool {
+		if checks[i].Problem != checks[j].Problem {
+			return checks[i].Problem < checks[j].Problem
+		}
+		if checks[i].Column != checks[j].Column {
+			return checks[i].Column > checks[j].Column
+		}
+		return lessMid(checks[i].Mid, checks[j].Mid)
+	})
+	for _, area := range c.bilingual.areas {
+		if area.names == 0 {
+			checks = append(checks, BilingualCheck{Problem: bilingualUnusedArea, Name: area.Language,
+				Municipality: area.Municipality, Settlement: area.Settlement})
+		}
+	}
+	return checks
+}
+
+// WriteBilingualChecksCSV saves the checks of the bilingual areas table
+func WriteBilingualChecksCSV(checks []BilingualCheck, filename string) error {
+	csvFile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer csvFile.Close()
+
+	writer := csv.NewWriter(csvFile)
+	if err := writer.Write([]string{"problem", "column", "mid", "name", "municipality", "settlement", "hs_count"}); err != nil {
+		return err
+	}
+	for _, check := range checks {
+		row := []string{check.Problem, check.Column, check.Mid, check.Name, check.Municipality, check.Settlement, strconv.Itoa(check.Records)}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return csvFile.Close()
+}
@@ -0,0 +1,91 @@
+package gurs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadBilingualAreas(t *testing.T) {
+	b, err := readBilingualAreas(filepath.Join(testOverridesDir, BilingualAreasFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, b.area("1", "Piran", "2", "Portorož").Language, "it")
+	assertEqual(t, b.area("3", "Koper/Capodistria", "4", "Koper/Capodistria").Language, "it")
+	assertEqual(t, b.area("5", "Moravske Toplice", "6", "Prosenjakovci").Language, "hu")
+	assertEqual(t, b.area("5", "Moravske Toplice", "7", "Tešanovci"), (*BilingualArea)(nil))
+	assertEqual(t, b.area("8", "Ljubljana", "9", "Ljubljana"), (*BilingualArea)(nil))
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, BilingualAreasFile)
+	content := "language,municipality,settlement\nit,302,\nsl,Ljubljana,\nhu,Lendava,\nhu,Lendava,\n"
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = readBilingualAreas(filename)
+	assertEqual(t, err.Error(), filename+`:3: unknown language "sl", should be it or hu`+"\n"+filename+":5: duplicate of line 4")
+
+	b, err = readBilingualAreas(filepath.Join(dir, "missing.csv"))
+	assertEqual(t, err, nil)
+	assertEqual(t, len(b.areas), 0)
+}
+
+func TestBilingualAreas(t *testing.T) {
+	_, _, features := convertTestData(t, testRecords)
+	assertEqual(t, features["1002"].Properties[tagStreet+":it"], "Piazza Giuseppe Tartini")
+
+	// language from the table, not the longitude
+	dataDir, overridesDir := t.TempDir(), t.TempDir()
+	writeTestData(t, dataDir, testRecords)
+	writeTestOverrides(t, overridesDir, "PT_UIME", "Piran - Pirano,Piran / Pirano\n")
+	if err := os.WriteFile(filepath.Join(overridesDir, BilingualAreasFile), []byte("hu,302,\nhu,Lendava,\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	converter := newTestConverter(t, dataDir)
+	converter.overridesDir = overridesDir
+	if err := converter.ReadLookups(); err != nil {
+		t.Fatal(err)
+	}
+	featureCollections, err := converter.ReadShapefile(openTestHS(t, converter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	piran := featureCollections["Piran/Piran"].Features[0]
+	assertEqual(t, piran.Properties[tagStreet+":hu"], "Piazza Giuseppe Tartini")
+	assertEqual(t, piran.Properties[tagCity+":hu"], "Pirano")
+	checks := converter.BilingualChecks()
+	assertEqual(t, len(checks), 1)
+	assertEqual(t, checks[0].Problem, bilingualUnusedArea)
+	assertEqual(t, checks[0].Municipality, "Lendava")
+
+	// no declared areas: guessed by longitude and reported
+	if err := os.Remove(filepath.Join(overridesDir, BilingualAreasFile)); err != nil {
+		t.Fatal(err)
+	}
+	if err := converter.ReadLookups(); err != nil {
+		t.Fatal(err)
+	}
+	featureCollections, err = converter.ReadShapefile(openTestHS(t, converter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, featureCollections["Piran/Piran"].Features[0].Properties[tagStreet+":it"], "Piazza Giuseppe Tartini")
+	checks = converter.BilingualChecks()
+	var problems []string
+	for _, check := range checks {
+		problems = append(problems, strings.Join([]string{check.Problem, check.Column, check.Mid, check.Name, check.Municipality}, " "))
+	}
+	assertEqual(t, strings.Join(problems, ","), "outside_area UL_DJ 102 Piazza Giuseppe Tartini Piran,outside_area PT_UIME 11027123 Pirano Piran")
+
+	filename := filepath.Join(t.TempDir(), "bilingual.csv")
+	if err := WriteBilingualChecksCSV(checks, filename); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, strings.Count(string(content), "\n"), 3)
+}
@@ -0,0 +1,208 @@
+package gurs
+
+import (
+	"math"
+
+	geojson "github.com/paulmach/go.geojson"
+	"github.com/paulmach/osm"
+)
+
+// cell size in meters of the building index, about the size of a house
+const buildingCellSize = 50
+
+// BuildingReason explains why an address was not attached to a building
+type BuildingReason string
+
+// reasons for addresses left as nodes
+const (
+	BuildingOutside           BuildingReason = "outside"
+	BuildingMultipleAddresses BuildingReason = "multiple_addresses"
+	BuildingTagged            BuildingReason = "tagged_building"
+	BuildingOverlapping       BuildingReason = "overlapping_buildings"
+)
+
+// BuildingReport is an unmatched address left as a node with the buildings containing it
+type BuildingReport struct {
+	Feature   *geojson.Feature
+	Reason    BuildingReason
+	Buildings []*OsmObject
+}
+
+// IsBuilding selects building ways and multipolygon relations
+func IsBuilding(tags osm.Tags) bool {
+	building := tags.Find("building")
+	return building != "" && building != "no"
+}
+
+// hasAddressTags returns true if any tag is an addr:* or GURS source tag
+func (p *TaggingProfile) hasAddressTags(tags osm.Tags) bool {
+	for _, tag := range tags {
+		if p.isAddressTag(tag.Key) {
+			return true
+		}
+	}
+	return false
+}
+
+// building is an outline with all its rings, inner and outer ones
+type building struct {
+	object *OsmObject
+	rings  []osm.WayNodes
+}
+
+// newBuilding returns the outline of a closed way or a multipolygon relation, nil for other objects
+func newBuilding(o *OsmObject) *building {
+	switch object := o.Object.(type) {
+	case *osm.Way:
+		if len(object.Nodes) < 4 || object.Nodes[0].ID != object.Nodes[len(object.Nodes)-1].ID {
+			return nil
+		}
+		return &building{object: o, rings: []osm.WayNodes{object.Nodes}}
+	case *osm.Relation:
+		if object.Tags.Find("type") != "multipolygon" {
+			return nil
+		}
+		b := &building{object: o}
+		for _, member := range object.Members {
+			if member.Type == osm.TypeWay && len(member.Nodes) > 1 {
+				b.rings = append(b.rings, member.Nodes)
+			}
+		}
+		if len(b.rings) == 0 {
+			return nil
+		}
+		return b
+	}
+	return nil
+}
+
+// bounds returns minLon, minLat, maxLon, maxLat of nodes with coordinates
+func (b *building) bounds() (float64, float64, float64, float64) {
+	minLon, minLat := math.Inf(1), math.Inf(1)
+	maxLon, maxLat := math.Inf(-1), math.Inf(-1)
+	for _, ring := range b.rings {
+		for _, node := range ring {
+			if node.Lon == 0 && node.Lat == 0 {
+				continue
+			}
+			minLon, maxLon = math.Min(minLon, node.Lon), math.Max(maxLon, node.Lon)
+			minLat, maxLat = math.Min(minLat, node.Lat), math.Max(maxLat, node.Lat)
+		}
+	}
+	return minLon, minLat, maxLon, maxLat
+}
+
+// contains tests the point with the even-odd rule over the segments of all rings,
+// so rings of multipolygons split into several ways need not be joined
+func (b *building) contains(lon, lat float64) bool {
+	inside := false
+	for _, ring := range b.rings {
+		for i := 1; i < len(ring); i++ {
+			a, c := ring[i-1], ring[i]
+			if (a.Lon == 0 && a.Lat == 0) || (c.Lon == 0 && c.Lat == 0) {
+				// node missing in the extract
+				continue
+			}
+			if (a.Lat > lat) != (c.Lat > lat) && lon < a.Lon+(lat-a.Lat)*(c.Lon-a.Lon)/(c.Lat-a.Lat) {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// buildingIndex finds building outlines containing a point
+type buildingIndex struct {
+	buildings []*building
+	index     *boxIndex
+	// buildings which got an address in previous Conflate calls
+	attached map[int]bool
+	// addresses of the datasets added with AddDataset by building, and their refs
+	registered     map[int]int
+	registeredRefs map[string]bool
+	profile        *TaggingProfile
+}
+
+func newBuildingIndex(objects []*OsmObject, profile *TaggingProfile) *buildingIndex {
+	index := &buildingIndex{index: newBoxIndex(buildingCellSize), attached: make(map[int]bool),
+		registered: make(map[int]int), registeredRefs: make(map[string]bool), profile: profile}
+	for _, o := range objects {
+		if !IsBuilding(o.Tags) {
+			continue
+		}
+		if b := newBuilding(o); b != nil {
+			index.buildings = append(index.buildings, b)
+			index.index.insert(b.bounds())
+		}
+	}
+	return index
+}
+
+// containing returns ids of buildings containing the point
+func (index *buildingIndex) containing(point []float64) []int {
+	var ids []int
+	for _, id := range index.index.containing(point[0], point[1]) {
+		if index.buildings[id].contains(point[0], point[1]) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// register counts the addresses with a ref of a dataset added with AddDataset in the buildings containing them
+func (index *buildingIndex) register(f *geojson.Feature, ref string) {
+	index.registeredRefs[ref] = true
+	for _, id := range index.containing(f.Geometry.Point) {
+		index.registered[id]++
+	}
+}
+
+// attach moves unmatched addresses alone in a building without address tags to conflation.Attached,
+// all other unmatched addresses are reported in conflation.Unattached.
+// The addresses in a building are those of all datasets added with AddDataset and of the features not added
+func (index *buildingIndex) attach(conflation *Conflation, features []*geojson.Feature) {
+	addresses := make(map[int]int)
+	for _, f := range features {
+		if index.registeredRefs[f.PropertyMustString(index.profile.Keys.Ref, "")] {
+			continue
+		}
+		for _, id := range index.containing(f.Geometry.Point) {
+			addresses[id]++
+		}
+	}
+
+	var unmatched []*geojson.Feature
+	for _, f := range conflation.UnmatchedDataset {
+		ids := index.containing(f.Geometry.Point)
+		report := BuildingReport{Feature: f}
+		for _, id := range ids {
+			report.Buildings = append(report.Buildings, index.buildings[id].object)
+		}
+
+		switch {
+		case len(ids) == 0:
+			report.Reason = BuildingOutside
+		case len(ids) > 1:
+			report.Reason = BuildingOverlapping
+		case addresses[ids[0]]+index.registered[ids[0]] > 1:
+			report.Reason = BuildingMultipleAddresses
+		case index.attached[ids[0]] || index.profile.hasAddressTags(index.buildings[ids[0]].object.Tags):
+			report.Reason = BuildingTagged
+		default:
+			index.attached[ids[0]] = true
+			o := index.buildings[ids[0]].object
+			conflation.Attached = append(conflation.Attached, Match{Feature: f, Object: o,
+				Distance: Distance(f.Geometry.Point[0], f.Geometry.Point[1], o.Lon, o.Lat),
+				Tags:     withAddress(o.Tags, f), Changed: true})
+			continue
+		}
+		conflation.Unattached = append(conflation.Unattached, report)
+		unmatched = append(unmatched, f)
+	}
+	conflation.UnmatchedDataset = unmatched
+}
+
+// withAddress returns a copy of tags with all tags of the feature added
+func withAddress(tags osm.Tags, f *geojson.Feature) osm.Tags {
+	return append(append(osm.Tags(nil), tags...), newOsmNode(0, f).Tags...)
+}
@@ -0,0 +1,143 @@
+package gurs
+
+import (
+	"testing"
+
+	geojson "github.com/paulmach/go.geojson"
+	"github.com/paulmach/osm"
+)
+
+func isAddressedOrBuilding(tags osm.Tags) bool {
+	return DefaultTaggingProfile().IsAddressed(tags) || IsBuilding(tags)
+}
+
+func TestBuildingContains(t *testing.T) {
+	// outer ring split into two ways, with a hole
+	ring := func(coordinates ...float64) osm.WayNodes {
+		var nodes osm.WayNodes
+		for i := 0; i < len(coordinates); i += 2 {
+			nodes = append(nodes, osm.WayNode{Lon: coordinates[i], Lat: coordinates[i+1]})
+		}
+		return nodes
+	}
+	relation := &osm.Relation{ID: 1, Tags: osm.Tags{{Key: "type", Value: "multipolygon"}, {Key: "building", Value: "yes"}},
+		Members: osm.Members{
+			{Type: osm.TypeWay, Role: "outer", Nodes: ring(14, 46, 14.1, 46, 14.1, 46.1)},
+			{Type: osm.TypeWay, Role: "outer", Nodes: ring(14.1, 46.1, 14, 46.1, 14, 46)},
+			{Type: osm.TypeWay, Role: "inner", Nodes: ring(14.04, 46.04, 14.06, 46.04, 14.06, 46.06, 14.04, 46.06, 14.04, 46.04)},
+		}}
+	b := newBuilding(&OsmObject{Object: relation, Tags: relation.Tags})
+	assertEqual(t, b.contains(14.02, 46.02), true)
+	assertEqual(t, b.contains(14.05, 46.05), false)
+	assertEqual(t, b.contains(14.2, 46.05), false)
+
+	open := &osm.Way{ID: 2, Nodes: ring(14, 46, 14.1, 46, 14.1, 46.1, 14, 46.1)}
+	for i := range open.Nodes {
+		open.Nodes[i].ID = osm.NodeID(i + 1)
+	}
+	assertEqual(t, newBuilding(&OsmObject{Object: open}), (*building)(nil))
+}
+
+func TestAttachToBuildings(t *testing.T) {
+	_, featureCollections, features := convertTestData(t, testRecords)
+	zgornja, cesta, ljubljana := features["1003"].Geometry.Point, features["1004"].Geometry.Point, features["1001"].Geometry.Point
+
+	extract := osm.OSM{}
+	single := squareNodes(30, zgornja)
+	multiple := squareNodes(40, cesta)
+	outer := squareNodes(50, ljubljana)
+	for i := range outer {
+		// 10 times larger than the inner ring
+		outer[i].Lon = ljubljana[0] + (outer[i].Lon-ljubljana[0])*10
+		outer[i].Lat = ljubljana[1] + (outer[i].Lat-ljubljana[1])*10
+	}
+	inner := squareNodes(60, ljubljana)
+	for _, nodes := range []osm.Nodes{single, multiple, outer, inner} {
+		extract.Nodes = append(extract.Nodes, nodes...)
+	}
+	extract.Ways = osm.Ways{
+		closedWay(20, single, osm.Tags{{Key: "building", Value: "house"}}),
+		closedWay(21, multiple, osm.Tags{{Key: "building", Value: "yes"}}),
+		closedWay(22, outer, nil),
+		closedWay(23, inner, nil),
+	}
+	extract.Relations = osm.Relations{{ID: 40, Version: 1, Visible: true,
+		Members: osm.Members{{Type: osm.TypeWay, Ref: 22, Role: "outer"}, {Type: osm.TypeWay, Ref: 23, Role: "inner"}},
+		Tags:    osm.Tags{{Key: "type", Value: "multipolygon"}, {Key: "building", Value: "yes"}}}}
+
+	osmExtract, err := ReadOsmExtract(writeTestExtract(t, extract), isAddressedOrBuilding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := DefaultConflationOptions()
+	options.AttachToBuildings = true
+	conflator := NewConflator(osmExtract, options)
+
+	second := geojson.NewPointFeature([]float64{cesta[0] + 0.00002, cesta[1]})
+	second.Properties = map[string]interface{}{tagHousenumber: "7a", tagPostCode: "1000", tagStreet: "Cesta slovenskih kmečkih uporov"}
+	featureCollection := &geojson.FeatureCollection{Features: append(featureCollections["Ljubljana/Zgornja_vas"].Features, second)}
+
+	conflation := conflator.Conflate(featureCollection)
+	assertEqual(t, len(conflation.Matched), 0)
+	assertEqual(t, len(conflation.UnmatchedOSM), 0)
+	assertEqual(t, len(conflation.Attached), 1)
+	assertEqual(t, conflation.Attached[0].Object.String(), "way/20")
+	assertEqual(t, conflation.Attached[0].Feature, features["1003"])
+	assertEqual(t, len(conflation.UnmatchedDataset), 2)
+	assertEqual(t, len(conflation.Unattached), 2)
+	assertEqual(t, conflation.Unattached[0].Reason, BuildingMultipleAddresses)
+	assertEqual(t, conflation.Unattached[0].Buildings[0].String(), "way/21")
+
+	change := conflation.OsmChange()
+	assertEqual(t, len(change.Create.Nodes), 2)
+	assertEqual(t, len(change.Modify.Ways), 1)
+	modified := change.Modify.Ways[0]
+	assertEqual(t, modified.ID, osm.WayID(20))
+	assertEqual(t, modified.Version, 4)
+	assertEqual(t, modified.Tags.Find("building"), "house")
+	assertEqual(t, modified.Tags.Find(tagHousenumber), "5")
+	assertEqual(t, modified.Tags.Find(tagRef), "1003")
+
+	preview := conflation.Preview()
+	assertEqual(t, preview.Features[0].Properties["matched_by"], "building")
+	assertEqual(t, preview.Features[1].Properties["building"], string(BuildingMultipleAddresses))
+
+	// in the hole of the multipolygon
+	conflation = conflator.Conflate(featureCollections["Ljubljana/Ljubljana"])
+	assertEqual(t, len(conflation.Attached), 0)
+	assertEqual(t, len(conflation.Unattached), 1)
+	assertEqual(t, conflation.Unattached[0].Reason, BuildingOutside)
+}
+
+func TestAttachToBuildingsDatasets(t *testing.T) {
+	_, _, features := convertTestData(t, testRecords)
+	zgornja := features["1003"].Geometry.Point
+
+	extract := osm.OSM{}
+	extract.Nodes = squareNodes(30, zgornja)
+	extract.Ways = osm.Ways{closedWay(20, extract.Nodes, osm.Tags{{Key: "building", Value: "house"}})}
+	osmExtract, err := ReadOsmExtract(writeTestExtract(t, extract), isAddressedOrBuilding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := DefaultConflationOptions()
+	options.AttachToBuildings = true
+	conflator := NewConflator(osmExtract, options)
+
+	// the same building on the border of two settlements, one address in each
+	neighbour := geojson.NewPointFeature([]float64{zgornja[0] + 0.00002, zgornja[1]})
+	neighbour.Properties = map[string]interface{}{tagHousenumber: "1", tagPostCode: "1000", tagStreet: "Slovenska cesta", tagRef: "1006"}
+	datasets := []*geojson.FeatureCollection{
+		{Features: []*geojson.Feature{features["1003"]}},
+		{Features: []*geojson.Feature{neighbour}},
+	}
+	for _, dataset := range datasets {
+		conflator.AddDataset(dataset)
+	}
+	for _, dataset := range datasets {
+		conflation := conflator.Conflate(dataset)
+		assertEqual(t, len(conflation.Attached), 0)
+		assertEqual(t, len(conflation.Unattached), 1)
+		assertEqual(t, conflation.Unattached[0].Reason, BuildingMultipleAddresses)
+	}
+}
@@ -0,0 +1,503 @@
+package gurs
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	geojson "github.com/paulmach/go.geojson"
+	"github.com/paulmach/osm"
+)
+
+// default settings, same as in gursAddressesConflationProfile.py
+const (
+	DefaultMaxDistance       = 20
+	DefaultDuplicateDistance = 0
+)
+
+// DefaultMasterTags are replaced on matched OSM objects, the master tags of DefaultTaggingProfile,
+// also read by gursAddressesConflationProfile.py
+var DefaultMasterTags = DefaultTaggingProfile().MasterKeys()
+
+// ConflationOptions mirror the settings of gursAddressesConflationProfile.py
+type ConflationOptions struct {
+	// max_distance: maximum distance in meters between a GURS address and the matched OSM object centroid
+	MaxDistance float64
+	// duplicate_distance: GURS addresses with the same tags closer than this (in meters) are duplicates,
+	// unmatched duplicates are not created (the first one of each group is), negative to disable
+	DuplicateDistance float64
+	// delete_unmatched: unmatched OSM objects around the dataset are deleted (nodes with address tags only)
+	// or lose their address tags
+	DeleteUnmatched bool
+	// master_tags: replaced on matched OSM objects, all other tags are left as they are
+	MasterTags []string
+	// keys of the converted addresses, DefaultTaggingProfile if nil
+	Profile *TaggingProfile
+	// unmatched addresses alone in a building outline without address tags are added to the building
+	AttachToBuildings bool
+	// generator attribute of OsmChange files
+	Generator string
+}
+
+// DefaultConflationOptions returns the settings of gursAddressesConflationProfile.py
+func DefaultConflationOptions() ConflationOptions {
+	return ConflationOptions{
+		MaxDistance:       DefaultMaxDistance,
+		DuplicateDistance: DefaultDuplicateDistance,
+		DeleteUnmatched:   false,
+		MasterTags:        DefaultMasterTags,
+		Profile:           DefaultTaggingProfile(),
+		Generator:         DefaultOsmGenerator,
+	}
+}
+
+// Match pairs a GURS address with an OSM object
+type Match struct {
+	Feature  *geojson.Feature
+	Object   *OsmObject
+	Distance float64
+	// matched by ref:gurs:hs_mid instead of distance
+	ByRef bool
+	// OSM tags with master tags applied, changed if they differ from the object tags
+	Tags    osm.Tags
+	Changed bool
+}
+
+// unmatched OSM object actions with DeleteUnmatched
+const (
+	actionDelete = "delete"
+	actionRetag  = "retag"
+)
+
+// Conflation is the result of conflating the addresses of one category
+type Conflation struct {
+	Matched []Match
+	// GURS addresses without an OSM object, to be created
+	UnmatchedDataset []*geojson.Feature
+	// unmatched GURS addresses with the same tags at the same place as another one, not created
+	UnmatchedDuplicates []*geojson.Feature
+	// OSM objects within the bounding box of the dataset (extended by MaxDistance), not matched,
+	// not listed by a previous Conflate call and not matchable by a dataset added with AddDataset and not conflated yet
+	UnmatchedOSM []*OsmObject
+	// with AttachToBuildings: buildings getting the tags of an unmatched address
+	Attached []Match
+	// with AttachToBuildings: unmatched addresses left as nodes and why
+	Unattached []BuildingReport
+
+	// counts for the summary
+	read, duplicates, downloaded int
+	options                      ConflationOptions
+}
+
+// Conflator matches GURS addresses to objects of one OSM extract,
+// by ref:gurs:hs_mid where OSM objects have it, by distance otherwise
+type Conflator struct {
+	objects []*OsmObject
+	// objects without ref:gurs:hs_mid by centroid
+	index *gridIndex
+	// objects with ref:gurs:hs_mid (index ids) by ref
+	refs map[string][]int
+	// objects matched by previous Conflate calls, by index id
+	matched map[int]bool
+	// refs of all conflated GURS addresses
+	datasetRefs map[string]bool
+	// addresses of the datasets added with AddDataset, done once conflated
+	pending *gridIndex
+	done    []bool
+	// pending addresses (index ids) by ref, removed once conflated
+	pendingRefs map[string][]int
+	// unmatched objects listed by previous Conflate calls, by index id
+	listed map[int]bool
+	// with AttachToBuildings
+	buildings *buildingIndex
+	options   ConflationOptions
+}
+
+// NewConflator indexes the addressed extract objects by ref:gurs:hs_mid and centroid,
+// and building outlines with AttachToBuildings
+func NewConflator(extract *OsmExtract, options ConflationOptions) *Conflator {
+	if options.Profile == nil {
+		options.Profile = DefaultTaggingProfile()
+	}
+	c := &Conflator{
+		index:       newGridIndex(math.Max(options.MaxDistance, 1)),
+		refs:        make(map[string][]int),
+		matched:     make(map[int]bool),
+		datasetRefs: make(map[string]bool),
+		pending:     newGridIndex(math.Max(options.MaxDistance, 1)),
+		pendingRefs: make(map[string][]int),
+		listed:      make(map[int]bool),
+		options:     options,
+	}
+	for _, o := range extract.Objects {
+		if options.Profile.IsAddressed(o.Tags) {
+			c.objects = append(c.objects, o)
+		}
+	}
+	if options.AttachToBuildings {
+		c.buildings = newBuildingIndex(extract.Objects, options.Profile)
+	}
+	for _, o := range c.objects {
+		// ids of the index and objects must match, objects with refs are inserted but never matched by distance
+		id := c.index.insert(o.Lon, o.Lat)
+		if ref := o.Tags.Find(options.Profile.Keys.Ref); ref != "" {
+			c.refs[ref] = append(c.refs[ref], id)
+		}
+	}
+	return c
+}
+
+// AddDataset registers the addresses (with a ref) of a dataset conflated later: Conflate does not list OSM objects
+// which an address of a dataset not conflated yet could still match (by ref or within MaxDistance) as unmatched,
+// and with AttachToBuildings counts the addresses of all datasets in a building.
+// Add all datasets before conflating the first one, otherwise an object around two datasets can be listed as unmatched
+// by the first and matched by the second one.
+func (c *Conflator) AddDataset(featureCollection *geojson.FeatureCollection) {
+	for _, f := range featureCollection.Features {
+		if ref := f.PropertyMustString(c.options.Profile.Keys.Ref, ""); ref != "" {
+			c.pendingRefs[ref] = append(c.pendingRefs[ref], c.pending.insert(f.Geometry.Point[0], f.Geometry.Point[1]))
+			c.done = append(c.done, false)
+			if c.buildings != nil {
+				c.buildings.register(f, ref)
+			}
+		}
+	}
+}
+
+// matchable returns true if an address of a dataset added with AddDataset and not conflated yet could match the object
+func (c *Conflator) matchable(o *OsmObject) bool {
+	if ref := o.Tags.Find(c.options.Profile.Keys.Ref); ref != "" {
+		// objects with a ref are only matched by it
+		_, pending := c.pendingRefs[ref]
+		return pending
+	}
+	for _, id := range c.pending.within(o.Lon, o.Lat, c.options.MaxDistance) {
+		if !c.done[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// candidate is a possible match of feature and object (by index) within MaxDistance
+type candidate struct {
+	feature, object int
+	distance        float64
+}
+
+// Conflate matches the addresses to OSM objects with the same ref:gurs:hs_mid (the nearest one if there are more),
+// then the rest to the nearest OSM objects without a ref within MaxDistance, closest pairs first.
+// Each OSM object is matched once, also across Conflate calls.
+func (c *Conflator) Conflate(featureCollection *geojson.FeatureCollection) *Conflation {
+	SortFeatureCollection(*featureCollection, c.options.Profile, DefaultSortOrder)
+	features := featureCollection.Features
+	conflation := &Conflation{read: len(features), options: c.options}
+
+	duplicate := c.findDuplicates(features)
+	for _, isDuplicate := range duplicate {
+		if isDuplicate {
+			conflation.duplicates++
+		}
+	}
+
+	usedObject := make(map[int]bool)
+	matchedObject := make(map[int]int)
+	matchedByRef := make(map[int]bool)
+	for _, f := range features {
+		ref := f.PropertyMustString(c.options.Profile.Keys.Ref, "")
+		for _, id := range c.pendingRefs[ref] {
+			c.done[id] = true
+		}
+		delete(c.pendingRefs, ref)
+	}
+
+	for i, f := range features {
+		ref := f.PropertyMustString(c.options.Profile.Keys.Ref, "")
+		c.datasetRefs[ref] = true
+
+		nearest, nearestDistance := -1, math.Inf(1)
+		for _, id := range c.refs[ref] {
+			o := c.objects[id]
+			if distance := Distance(f.Geometry.Point[0], f.Geometry.Point[1], o.Lon, o.Lat); !c.matched[id] && distance < nearestDistance {
+				nearest, nearestDistance = id, distance
+			}
+		}
+		if nearest >= 0 {
+			matchedObject[i] = nearest
+			matchedByRef[i] = true
+			usedObject[nearest] = true
+			c.matched[nearest] = true
+		}
+	}
+
+	var candidates []candidate
+	for i, f := range features {
+		if matchedByRef[i] {
+			continue
+		}
+		for _, id := range c.index.within(f.Geometry.Point[0], f.Geometry.Point[1], c.options.MaxDistance) {
+			if !c.matched[id] && !c.objects[id].Tags.HasTag(c.options.Profile.Keys.Ref) {
+				o := c.objects[id]
+				candidates = append(candidates, candidate{i, id, Distance(f.Geometry.Point[0], f.Geometry.Point[1], o.Lon, o.Lat)})
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		if candidates[i].feature != candidates[j].feature {
+			return candidates[i].feature < candidates[j].feature
+		}
+		return candidates[i].object < candidates[j].object
+	})
+
+	for _, pair := range candidates {
+		if _, used := matchedObject[pair.feature]; used || usedObject[pair.object] {
+			continue
+		}
+		matchedObject[pair.feature] = pair.object
+		usedObject[pair.object] = true
+		c.matched[pair.object] = true
+	}
+
+	for i, f := range features {
+		id, found := matchedObject[i]
+		switch {
+		case found:
+			o := c.objects[id]
+			tags, changed := applyMasterTags(o.Tags, f, c.options.MasterTags, c.options.Profile.Keys)
+			conflation.Matched = append(conflation.Matched, Match{Feature: f, Object: o, ByRef: matchedByRef[i],
+				Distance: Distance(f.Geometry.Point[0], f.Geometry.Point[1], o.Lon, o.Lat), Tags: tags, Changed: changed})
+		case duplicate[i]:
+			conflation.UnmatchedDuplicates = append(conflation.UnmatchedDuplicates, f)
+		default:
+			conflation.UnmatchedDataset = append(conflation.UnmatchedDataset, f)
+		}
+	}
+
+	if c.buildings != nil {
+		c.buildings.attach(conflation, features)
+	}
+
+	around := c.objectsAround(features)
+	conflation.downloaded = len(around)
+	for _, id := range around {
+		if !c.matched[id] && !c.listed[id] && !c.matchable(c.objects[id]) {
+			conflation.UnmatchedOSM = append(conflation.UnmatchedOSM, c.objects[id])
+			c.listed[id] = true
+		}
+	}
+
+	return conflation
+}
+
+// findDuplicates flags features with the same tags (except the ref) as a previous, not duplicate feature within
+// DuplicateDistance, one address of each group is kept
+func (c *Conflator) findDuplicates(features []*geojson.Feature) []bool {
+	duplicate := make([]bool, len(features))
+	if c.options.DuplicateDistance < 0 {
+		return duplicate
+	}
+
+	index := newGridIndex(math.Max(c.options.DuplicateDistance, 1))
+	for _, f := range features {
+		index.insert(f.Geometry.Point[0], f.Geometry.Point[1])
+	}
+	for i, f := range features {
+		for _, id := range index.within(f.Geometry.Point[0], f.Geometry.Point[1], c.options.DuplicateDistance) {
+			if id < i && !duplicate[id] && c.sameAddress(f, features[id]) {
+				duplicate[i] = true
+				break
+			}
+		}
+	}
+	return duplicate
+}
+
+// sameAddress returns true if both features have the same tags, the ref is ignored
+func (c *Conflator) sameAddress(a, b *geojson.Feature) bool {
+	return c.containsTags(a, b) && c.containsTags(b, a)
+}
+
+// containsTags returns true if b has all tags of a, except the ref
+func (c *Conflator) containsTags(a, b *geojson.Feature) bool {
+	for key, value := range a.Properties {
+		if other, found := b.Properties[key]; key != c.options.Profile.Keys.Ref && (!found || other != value) {
+			return false
+		}
+	}
+	return true
+}
+
+// objectsAround returns ids of OSM objects in the bounding box of the features extended by MaxDistance
+func (c *Conflator) objectsAround(features []*geojson.Feature) []int {
+	if len(features) == 0 {
+		return nil
+	}
+
+	minLon, minLat := math.Inf(1), math.Inf(1)
+	maxLon, maxLat := math.Inf(-1), math.Inf(-1)
+	for _, f := range features {
+		minLon, maxLon = math.Min(minLon, f.Geometry.Point[0]), math.Max(maxLon, f.Geometry.Point[0])
+		minLat, maxLat = math.Min(minLat, f.Geometry.Point[1]), math.Max(maxLat, f.Geometry.Point[1])
+	}
+	margin := c.options.MaxDistance / metersPerDegree
+	lonMargin := margin / math.Cos(degreesToRadians(maxLat))
+	return c.index.inBox(minLon-lonMargin, minLat-margin, maxLon+lonMargin, maxLat+margin)
+}
+
+// unmatchedAction returns what happens to an unmatched OSM object: delete, retag or nothing
+func (conflation *Conflation) unmatchedAction(o *OsmObject) string {
+	if !conflation.options.DeleteUnmatched {
+		return ""
+	}
+	if _, isNode := o.Object.(*osm.Node); isNode && len(conflation.options.Profile.withoutAddressTags(o.Tags)) == 0 {
+		return actionDelete
+	}
+	return actionRetag
+}
+
+// isAddressTag returns true for addr:* and the other keys of the profile (GURS source tags)
+func (p *TaggingProfile) isAddressTag(key string) bool {
+	if strings.HasPrefix(key, "addr:") {
+		return true
+	}
+	for _, profileKey := range p.Keys.byName() {
+		if key == profileKey && key != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *TaggingProfile) withoutAddressTags(tags osm.Tags) osm.Tags {
+	var kept osm.Tags
+	for _, tag := range tags {
+		if !p.isAddressTag(tag.Key) {
+			kept = append(kept, tag)
+		}
+	}
+	return kept
+}
+
+// Summary returns the counts in the same words as the Python conflate log, parsed by summarize.sh
+func (conflation *Conflation) Summary() string {
+	deleted, retagged := 0, 0
+	for _, o := range conflation.UnmatchedOSM {
+		switch conflation.unmatchedAction(o) {
+		case actionDelete:
+			deleted++
+		case actionRetag:
+			retagged++
+		}
+	}
+	modified, byRef := 0, 0
+	for _, match := range conflation.Matched {
+		if match.Changed {
+			modified++
+		}
+		if match.ByRef {
+			byRef++
+		}
+	}
+
+	lines := []string{
+		fmt.Sprintf("Read %d items from the dataset", conflation.read),
+		fmt.Sprintf("Found %d duplicates in the dataset", conflation.duplicates),
+		fmt.Sprintf("Downloaded %d objects from OSM", conflation.downloaded),
+		fmt.Sprintf("Updated %d OSM objects with %s tag", byRef, conflation.options.Profile.Keys.Ref),
+		fmt.Sprintf("Matched %d points", len(conflation.Matched)-byRef),
+		fmt.Sprintf("Modified %d matched OSM objects", modified),
+		fmt.Sprintf("Removed %d unmatched duplicates", len(conflation.UnmatchedDuplicates)),
+		fmt.Sprintf("Deleted %d and retagged %d unmatched objects from OSM", deleted, retagged),
+		fmt.Sprintf("Adding %d unmatched dataset points", len(conflation.UnmatchedDataset)),
+	}
+	if conflation.options.AttachToBuildings {
+		unattached := make(map[BuildingReason]int)
+		for _, report := range conflation.Unattached {
+			unattached[report.Reason]++
+		}
+		lines = append(lines,
+			fmt.Sprintf("Attached %d addresses to buildings", len(conflation.Attached)),
+			fmt.Sprintf("Left %d addresses outside of buildings, %d in buildings with more addresses, %d in tagged and %d in overlapping buildings",
+				unattached[BuildingOutside], unattached[BuildingMultipleAddresses], unattached[BuildingTagged], unattached[BuildingOverlapping]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// StaleRefs returns OSM objects with a ref:gurs:hs_mid not found in any address conflated so far,
+// sorted by ref, complete only after all categories are conflated
+func (c *Conflator) StaleRefs() []*OsmObject {
+	var refs []string
+	for ref := range c.refs {
+		if !c.datasetRefs[ref] {
+			refs = append(refs, ref)
+		}
+	}
+	sortMids(refs)
+
+	var stale []*OsmObject
+	for _, ref := range refs {
+		for _, id := range c.refs[ref] {
+			stale = append(stale, c.objects[id])
+		}
+	}
+	return stale
+}
+
+// DuplicateRefs returns OSM objects sharing their ref:gurs:hs_mid with another object, by ref
+func (c *Conflator) DuplicateRefs() map[string][]*OsmObject {
+	duplicates := make(map[string][]*OsmObject)
+	for ref, ids := range c.refs {
+		if len(ids) > 1 {
+			for _, id := range ids {
+				duplicates[ref] = append(duplicates[ref], c.objects[id])
+			}
+		}
+	}
+	return duplicates
+}
+
+// WriteRefReport saves stale and duplicate ref:gurs:hs_mid tags in OSM to a CSV file
+func (c *Conflator) WriteRefReport(filename string) error {
+	csvFile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer csvFile.Close()
+
+	writer := csv.NewWriter(csvFile)
+	if err := writer.Write([]string{"problem", "ref", "osm_objects"}); err != nil {
+		return err
+	}
+	for _, o := range c.StaleRefs() {
+		if err := writer.Write([]string{"stale", o.Tags.Find(c.options.Profile.Keys.Ref), o.String()}); err != nil {
+			return err
+		}
+	}
+
+	duplicates := c.DuplicateRefs()
+	refs := make([]string, 0, len(duplicates))
+	for ref := range duplicates {
+		refs = append(refs, ref)
+	}
+	sortMids(refs)
+	for _, ref := range refs {
+		var objects []string
+		for _, o := range duplicates[ref] {
+			objects = append(objects, o.String())
+		}
+		if err := writer.Write([]string{"duplicate", ref, strings.Join(objects, " ")}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return csvFile.Close()
+}
@@ -0,0 +1,431 @@
+package gurs
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	geojson "github.com/paulmach/go.geojson"
+	"github.com/paulmach/osm"
+)
+
+// writeTestExtract saves the objects as an .osm file
+func writeTestExtract(t *testing.T, extract osm.OSM) string {
+	t.Helper()
+
+	extract.Version = "0.6"
+	rawXML, err := xml.Marshal(extract)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "extract.osm")
+	if err := os.WriteFile(filename, rawXML, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+// squareNodes returns 4 untagged nodes with ids from firstID around the point, about 4 m from it
+func squareNodes(firstID osm.NodeID, point []float64) osm.Nodes {
+	const d = 0.00005
+	return osm.Nodes{
+		{ID: firstID, Version: 1, Visible: true, Lon: point[0] - d, Lat: point[1] - d},
+		{ID: firstID + 1, Version: 1, Visible: true, Lon: point[0] + d, Lat: point[1] - d},
+		{ID: firstID + 2, Version: 1, Visible: true, Lon: point[0] + d, Lat: point[1] + d},
+		{ID: firstID + 3, Version: 1, Visible: true, Lon: point[0] - d, Lat: point[1] + d},
+	}
+}
+
+// closedWay returns a ring through the nodes
+func closedWay(id osm.WayID, nodes osm.Nodes, tags osm.Tags) *osm.Way {
+	way := &osm.Way{ID: id, Version: 4, Visible: true, Tags: tags}
+	for _, node := range append(nodes, nodes[0]) {
+		way.Nodes = append(way.Nodes, osm.WayNode{ID: node.ID})
+	}
+	return way
+}
+
+// testConflationExtract has objects around the test records:
+// node 10 same as 1001, relation 40 10 m from it, node 11 with a wrong post code near 1004,
+// way 20 around 1003, node 14 between 1003 and 1004, node 12 far away, node 13 not an address
+func testConflationExtract(t *testing.T, features map[string]*geojson.Feature) string {
+	t.Helper()
+
+	ljubljana, zgornja, cesta := features["1001"].Geometry.Point, features["1003"].Geometry.Point, features["1004"].Geometry.Point
+	extract := osm.OSM{Nodes: osm.Nodes{
+		{ID: 10, Version: 2, Visible: true, Lon: ljubljana[0], Lat: ljubljana[1], Tags: osm.Tags{
+			{Key: tagHousenumber, Value: "12"}, {Key: tagStreet, Value: "Slovenska cesta"},
+			{Key: tagPostCode, Value: "1000"}, {Key: tagCity, Value: "Ljubljana"}}},
+		{ID: 11, Version: 3, Visible: true, Lon: cesta[0] + 0.0001, Lat: cesta[1], Tags: osm.Tags{
+			{Key: "name", Value: "Gostilna"}, {Key: tagHousenumber, Value: "7"},
+			{Key: tagStreet, Value: "Cesta slovenskih kmečkih uporov"}, {Key: tagPostCode, Value: "1001"}}},
+		{ID: 12, Version: 1, Visible: true, Lon: cesta[0] + 0.01, Lat: cesta[1], Tags: osm.Tags{
+			{Key: tagHousenumber, Value: "5"}}},
+		{ID: 13, Version: 1, Visible: true, Lon: cesta[0], Lat: cesta[1], Tags: osm.Tags{{Key: "amenity", Value: "bench"}}},
+		{ID: 14, Version: 1, Visible: true, Lon: (zgornja[0] + cesta[0]) / 2, Lat: (zgornja[1] + cesta[1]) / 2, Tags: osm.Tags{
+			{Key: tagHousenumber, Value: "6"}}},
+	}}
+
+	building := squareNodes(30, zgornja)
+	extract.Nodes = append(extract.Nodes, building...)
+	extract.Ways = append(extract.Ways, closedWay(20, building, osm.Tags{
+		{Key: "building", Value: "house"}, {Key: tagHousenumber, Value: "5"}, {Key: tagStreet, Value: "Zgornja vas"}}))
+
+	outer := squareNodes(50, []float64{ljubljana[0], ljubljana[1] + 0.00009})
+	extract.Nodes = append(extract.Nodes, outer...)
+	extract.Ways = append(extract.Ways, closedWay(21, outer, nil))
+	extract.Relations = osm.Relations{{ID: 40, Version: 1, Visible: true,
+		Members: osm.Members{{Type: osm.TypeWay, Ref: 21, Role: "outer"}},
+		Tags:    osm.Tags{{Key: "type", Value: "multipolygon"}, {Key: "building", Value: "yes"}, {Key: tagHousenumber, Value: "12"}}}}
+
+	return writeTestExtract(t, extract)
+}
+
+func TestReadOsmExtract(t *testing.T) {
+	_, _, features := convertTestData(t, testRecords)
+
+	extract, err := ReadOsmExtract(testConflationExtract(t, features), DefaultTaggingProfile().IsAddressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, extract.Incomplete, 0)
+
+	objects := make(map[string]*OsmObject)
+	for _, o := range extract.Objects {
+		objects[o.String()] = o
+	}
+	assertEqual(t, len(objects), 6)
+	assertEqual(t, objects["node/13"], (*OsmObject)(nil))
+
+	// centroid without the closing node counted twice
+	zgornja := features["1003"].Geometry.Point
+	way := objects["way/20"]
+	assertBetween(t, int(Distance(way.Lon, way.Lat, zgornja[0], zgornja[1])*1000), -1, 1)
+	assertEqual(t, len(way.Object.(*osm.Way).Nodes), 5)
+
+	relation := objects["relation/40"]
+	assertEqual(t, len(relation.Object.(*osm.Relation).Members[0].Nodes), 5)
+	ljubljana := features["1001"].Geometry.Point
+	assertBetween(t, int(Distance(relation.Lon, relation.Lat, ljubljana[0], ljubljana[1])), 9, 11)
+}
+
+func TestConflate(t *testing.T) {
+	_, featureCollections, features := convertTestData(t, testRecords)
+	extract, err := ReadOsmExtract(testConflationExtract(t, features), DefaultTaggingProfile().IsAddressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conflator := NewConflator(extract, DefaultConflationOptions())
+
+	conflation := conflator.Conflate(featureCollections["Ljubljana/Ljubljana"])
+	assertEqual(t, len(conflation.Matched), 1)
+	assertEqual(t, conflation.Matched[0].Object.String(), "node/10")
+	assertEqual(t, conflation.Matched[0].Changed, false)
+	assertEqual(t, len(conflation.UnmatchedOSM), 1)
+	assertEqual(t, conflation.UnmatchedOSM[0].String(), "relation/40")
+	change := conflation.OsmChange()
+	assertEqual(t, change.Create, (*osm.OSM)(nil))
+	assertEqual(t, change.Modify, (*osm.OSM)(nil))
+
+	conflation = conflator.Conflate(featureCollections["Ljubljana/Zgornja_vas"])
+	assertEqual(t, len(conflation.Matched), 2)
+	assertEqual(t, len(conflation.UnmatchedDataset), 0)
+	assertEqual(t, len(conflation.UnmatchedOSM), 1)
+	assertEqual(t, conflation.UnmatchedOSM[0].String(), "node/14")
+	assertEqual(t, conflation.Summary(), strings.Join([]string{
+		"Read 2 items from the dataset",
+		"Found 0 duplicates in the dataset",
+		"Downloaded 3 objects from OSM",
+		"Updated 0 OSM objects with ref:gurs:hs_mid tag",
+		"Matched 2 points",
+		"Modified 2 matched OSM objects",
+		"Removed 0 unmatched duplicates",
+		"Deleted 0 and retagged 0 unmatched objects from OSM",
+		"Adding 0 unmatched dataset points",
+	}, "\n"))
+
+	change = conflation.OsmChange()
+	assertEqual(t, change.Create, (*osm.OSM)(nil))
+	assertEqual(t, change.Delete, (*osm.OSM)(nil))
+	assertEqual(t, len(change.Modify.Nodes), 1)
+	assertEqual(t, len(change.Modify.Ways), 1)
+
+	// only master tags are touched, version is kept
+	modified := change.Modify.Nodes[0]
+	assertEqual(t, modified.ID, osm.NodeID(11))
+	assertEqual(t, modified.Version, 3)
+	assertEqual(t, modified.Tags.Find("name"), "Gostilna")
+	assertEqual(t, modified.Tags.Find(tagPostCode), "1000")
+	assertEqual(t, modified.Tags.Find(tagVillage), "Zgornja vas")
+	assertEqual(t, modified.Tags.HasTag(tagRef), false)
+	assertEqual(t, modified.Tags.HasTag(tagSource), false)
+
+	way := change.Modify.Ways[0]
+	assertEqual(t, way.ID, osm.WayID(20))
+	assertEqual(t, way.Version, 4)
+	assertEqual(t, way.Tags.Find("building"), "house")
+	assertEqual(t, way.Tags.Find(tagPostCode), "1000")
+	assertEqual(t, way.Tags.Find(tagStreet), "Zgornja vas")
+	assertEqual(t, way.Tags.HasTag(tagPlace), false)
+	assertEqual(t, len(way.Nodes), 5)
+	assertEqual(t, way.Nodes[0], osm.WayNode{ID: 30})
+
+	// node 11 is already matched
+	conflation = conflator.Conflate(&geojson.FeatureCollection{Features: []*geojson.Feature{features["1004"]}})
+	assertEqual(t, len(conflation.Matched), 0)
+	assertEqual(t, len(conflation.UnmatchedDataset), 1)
+	created := conflation.OsmChange().Create.Nodes[0]
+	assertEqual(t, created.ID, osm.NodeID(-1))
+	assertEqual(t, created.Tags.Find(tagRef), "1004")
+
+	oscFileName := filepath.Join(t.TempDir(), "test.osc")
+	if err := WriteOsmChange(change, oscFileName); err != nil {
+		t.Fatal(err)
+	}
+	rawXML, err := os.ReadFile(oscFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// way nodes without the coordinates filled in by ReadOsmExtract
+	assertEqual(t, regexp.MustCompile(`<nd [^>]*lat=`).Match(rawXML), false)
+	var written osm.Change
+	if err := xml.Unmarshal(rawXML, &written); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, written.Generator, DefaultOsmGenerator)
+	assertEqual(t, written.Modify.Nodes[0].Version, 3)
+}
+
+func TestConflateByRef(t *testing.T) {
+	_, featureCollections, features := convertTestData(t, testRecords)
+
+	zgornja, cesta := features["1003"].Geometry.Point, features["1004"].Geometry.Point
+	extract, err := ReadOsmExtract(writeTestExtract(t, osm.OSM{Nodes: osm.Nodes{
+		// about 80 m away, too far for a geometric match
+		{ID: 60, Version: 1, Visible: true, Lon: cesta[0] + 0.001, Lat: cesta[1], Tags: osm.Tags{
+			{Key: tagHousenumber, Value: "7"}, {Key: tagRef, Value: "1004"}}},
+		// same ref twice, the nearer one is matched
+		{ID: 61, Version: 1, Visible: true, Lon: zgornja[0] + 0.0005, Lat: zgornja[1], Tags: osm.Tags{
+			{Key: tagHousenumber, Value: "5"}, {Key: tagRef, Value: "1003"}}},
+		{ID: 62, Version: 1, Visible: true, Lon: zgornja[0] + 0.00005, Lat: zgornja[1], Tags: osm.Tags{
+			{Key: tagHousenumber, Value: "5"}, {Key: tagRef, Value: "1003"}}},
+		// no longer in GURS
+		{ID: 63, Version: 1, Visible: true, Lon: cesta[0], Lat: cesta[1] + 0.00005, Tags: osm.Tags{
+			{Key: tagHousenumber, Value: "8"}, {Key: tagRef, Value: "999"}}},
+		// nearest to 1004 but without ref
+		{ID: 64, Version: 1, Visible: true, Lon: cesta[0], Lat: cesta[1], Tags: osm.Tags{
+			{Key: tagHousenumber, Value: "7"}}},
+	}}), DefaultTaggingProfile().IsAddressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conflator := NewConflator(extract, DefaultConflationOptions())
+
+	conflation := conflator.Conflate(featureCollections["Ljubljana/Zgornja_vas"])
+	assertEqual(t, len(conflation.Matched), 2)
+	matched := make(map[string]Match)
+	for _, match := range conflation.Matched {
+		matched[match.Feature.PropertyMustString(tagRef)] = match
+	}
+	assertEqual(t, matched["1003"].Object.String(), "node/62")
+	assertEqual(t, matched["1003"].ByRef, true)
+	assertEqual(t, matched["1004"].Object.String(), "node/60")
+	assertBetween(t, int(matched["1004"].Distance), 70, 90)
+	assertEqual(t, len(conflation.UnmatchedDataset), 0)
+	assertEqual(t, strings.Contains(conflation.Summary(), "Updated 2 OSM objects with ref:gurs:hs_mid tag\nMatched 0 points"), true)
+
+	// an object with a ref is never matched by distance
+	conflation = conflator.Conflate(&geojson.FeatureCollection{Features: []*geojson.Feature{features["1001"]}})
+	stale := conflator.StaleRefs()
+	assertEqual(t, len(stale), 1)
+	assertEqual(t, stale[0].String(), "node/63")
+
+	duplicates := conflator.DuplicateRefs()
+	assertEqual(t, len(duplicates), 1)
+	assertEqual(t, len(duplicates["1003"]), 2)
+
+	reportFileName := filepath.Join(t.TempDir(), "refs.csv")
+	if err := conflator.WriteRefReport(reportFileName); err != nil {
+		t.Fatal(err)
+	}
+	report, err := os.ReadFile(reportFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, string(report), "problem,ref,osm_objects\nstale,999,node/63\nduplicate,1003,node/61 node/62\n")
+}
+
+func TestConflateDeleteUnmatched(t *testing.T) {
+	_, featureCollections, features := convertTestData(t, testRecords)
+	extract, err := ReadOsmExtract(testConflationExtract(t, features), DefaultTaggingProfile().IsAddressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := DefaultConflationOptions()
+	options.DeleteUnmatched = true
+	conflator := NewConflator(extract, options)
+
+	change := conflator.Conflate(featureCollections["Ljubljana/Ljubljana"]).OsmChange()
+	retagged := change.Modify.Relations[0]
+	assertEqual(t, retagged.ID, osm.RelationID(40))
+	assertEqual(t, retagged.Tags.Find("building"), "yes")
+	assertEqual(t, retagged.Tags.HasTag(tagHousenumber), false)
+
+	conflation := conflator.Conflate(featureCollections["Ljubljana/Zgornja_vas"])
+	assertEqual(t, conflation.OsmChange().Delete.Nodes[0].ID, osm.NodeID(14))
+	preview := conflation.Preview()
+	deleted := preview.Features[len(preview.Features)-1]
+	assertEqual(t, deleted.Properties["action"], "delete")
+	assertEqual(t, deleted.Properties["tags_deleted."+tagHousenumber], "6")
+}
+
+func TestConflateDuplicates(t *testing.T) {
+	_, _, features := convertTestData(t, testRecords)
+	extract, err := ReadOsmExtract(writeTestExtract(t, osm.OSM{}), DefaultTaggingProfile().IsAddressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// another house number at the same place is not a duplicate
+	neighbour := geojson.NewPointFeature(features["1003"].Geometry.Point)
+	neighbour.Properties = map[string]interface{}{tagHousenumber: "5a", tagPostCode: "1000", tagStreet: "Zgornja vas", tagRef: "1006"}
+	// the same tags as 1003 with another ref
+	duplicate := geojson.NewPointFeature(features["1003"].Geometry.Point)
+	duplicate.Properties = make(map[string]interface{})
+	for key, value := range features["1003"].Properties {
+		duplicate.Properties[key] = value
+	}
+	duplicate.Properties[tagRef] = "1007"
+	featureCollection := &geojson.FeatureCollection{Features: []*geojson.Feature{features["1003"], neighbour, duplicate, features["1004"]}}
+
+	conflation := NewConflator(extract, DefaultConflationOptions()).Conflate(featureCollection)
+	assertEqual(t, conflation.duplicates, 1)
+	assertEqual(t, len(conflation.UnmatchedDuplicates), 1)
+	assertEqual(t, conflation.UnmatchedDuplicates[0].Properties[tagRef], "1007")
+	refs := make(map[string]bool)
+	for _, f := range conflation.UnmatchedDataset {
+		refs[f.PropertyMustString(tagRef)] = true
+	}
+	assertEqual(t, len(refs), 3)
+	assertEqual(t, refs["1003"], true)
+	assertEqual(t, refs["1006"], true)
+	assertEqual(t, refs["1004"], true)
+
+	actions := make(map[string]int)
+	for _, f := range conflation.Preview().Features {
+		actions[f.Properties["action"].(string)]++
+	}
+	assertEqual(t, actions["create"], 3)
+	assertEqual(t, actions[actionDuplicate], 1)
+}
+
+func TestConflationPreview(t *testing.T) {
+	_, featureCollections, features := convertTestData(t, testRecords)
+	extract, err := ReadOsmExtract(testConflationExtract(t, features), DefaultTaggingProfile().IsAddressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	preview := NewConflator(extract, DefaultConflationOptions()).Conflate(featureCollections["Ljubljana/Zgornja_vas"]).Preview()
+	assertEqual(t, len(preview.Features), 3)
+
+	actions := make(map[string]*geojson.Feature)
+	for _, f := range preview.Features {
+		actions[fmt.Sprintf("%v/%v", f.Properties["osm_type"], f.Properties["osm_id"])] = f
+	}
+	modified := actions["node/11"]
+	assertEqual(t, modified.Properties["action"], "modify")
+	assertEqual(t, modified.Properties["tags.name"], "Gostilna")
+	assertEqual(t, modified.Properties["tags_changed."+tagPostCode], "1001 --> 1000")
+	assertEqual(t, modified.Properties["tags_new."+tagVillage], "Zgornja vas")
+	assertEqual(t, actions["node/14"].Properties["action"], actionUnmatched)
+}
+
+func TestDefaultMasterTagsMatchProfile(t *testing.T) {
+	profile, err := os.ReadFile("../gursAddressesConflationProfile.py")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings := map[string]string{
+		"max_distance":       fmt.Sprint(DefaultMaxDistance),
+		"duplicate_distance": fmt.Sprint(DefaultDuplicateDistance),
+		"delete_unmatched":   "False",
+	}
+	for name, expected := range settings {
+		setting := regexp.MustCompile(`(?m)^` + name + ` = (.*)$`).FindSubmatch(profile)
+		if setting == nil {
+			t.Errorf("%s not found in the conflation profile", name)
+			continue
+		}
+		assertEqual(t, strings.TrimSpace(string(setting[1])), expected)
+	}
+
+	// master_tags are read from the tagging profile, see TestDefaultTaggingProfileMatchesFile
+	if !bytes.Contains(profile, []byte("open('"+DefaultTaggingProfileFile+"')")) || !bytes.Contains(profile, []byte("['master_tags']")) {
+		t.Error("the conflation profile should read master_tags from " + DefaultTaggingProfileFile)
+	}
+	if regexp.MustCompile(`(?m)^master_tags = \(.*'`).Match(profile) {
+		t.Error("master_tags should not be listed in the conflation profile")
+	}
+}
+
+func TestConflateDatasets(t *testing.T) {
+	// first spans the unmatched nodes, second has an address next to node 71
+	first := &geojson.FeatureCollection{Features: []*geojson.Feature{
+		testSortFeature("2001", "1000", "", "Prva ulica", "1", 14.5, 46),
+		testSortFeature("2002", "1000", "", "Prva ulica", "2", 14.502, 46),
+	}}
+	second := &geojson.FeatureCollection{Features: []*geojson.Feature{
+		testSortFeature("2003", "1000", "", "Druga ulica", "1", 14.501, 46.00005),
+		testSortFeature("2004", "1000", "", "Druga ulica", "2", 14.5025, 46.0001),
+	}}
+	extract, err := ReadOsmExtract(writeTestExtract(t, osm.OSM{Nodes: osm.Nodes{
+		// about 6 m from 2003, 77 m from 2001 and 2002
+		{ID: 71, Version: 1, Visible: true, Lon: 14.501, Lat: 46, Tags: osm.Tags{{Key: tagHousenumber, Value: "1"}}},
+		// in both bounding boxes, more than 20 m from all addresses
+		{ID: 72, Version: 1, Visible: true, Lon: 14.5016, Lat: 46.00003, Tags: osm.Tags{{Key: tagHousenumber, Value: "9"}}},
+		// ref of 2004, far from it
+		{ID: 73, Version: 1, Visible: true, Lon: 14.5005, Lat: 46, Tags: osm.Tags{{Key: tagHousenumber, Value: "2"}, {Key: tagRef, Value: "2004"}}},
+	}}), DefaultTaggingProfile().IsAddressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := DefaultConflationOptions()
+	options.DeleteUnmatched = true
+
+	unmatched := func(conflation *Conflation) string {
+		var objects []string
+		for _, o := range conflation.UnmatchedOSM {
+			objects = append(objects, o.String())
+		}
+		return strings.Join(objects, ",")
+	}
+
+	// without AddDataset the first dataset deletes the nodes the second one then matches
+	conflator := NewConflator(extract, options)
+	assertEqual(t, unmatched(conflator.Conflate(first)), "node/71,node/72,node/73")
+	assertEqual(t, len(conflator.Conflate(second).Matched), 2)
+
+	conflator = NewConflator(extract, options)
+	conflator.AddDataset(first)
+	conflator.AddDataset(second)
+	conflation := conflator.Conflate(first)
+	assertEqual(t, unmatched(conflation), "node/72")
+	assertEqual(t, len(conflation.OsmChange().Delete.Nodes), 1)
+
+	conflation = conflator.Conflate(second)
+	assertEqual(t, unmatched(conflation), "")
+	matched := make(map[string]string)
+	for _, match := range conflation.Matched {
+		matched[match.Object.String()] = match.Feature.PropertyMustString(tagRef)
+	}
+	assertEqual(t, matched["node/71"], "2003")
+	assertEqual(t, matched["node/73"], "2004")
+	assertEqual(t, conflation.OsmChange().Delete, (*osm.OSM)(nil))
+}
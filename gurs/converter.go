@@ -0,0 +1,514 @@
+// Package gurs converts GURS (Geodetska uprava Republike Slovenije) RPE address data
+// (Register prostorskih enot) to OpenStreetMap tagged GeoJSON features.
+package gurs
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	shp "github.com/jonas-p/go-shp"
+	geojson "github.com/paulmach/go.geojson"
+)
+
+var reAllowedAbbreviations = regexp.MustCompile(`([IVX]+|[0-9]+|(D|d)r|(S|s)v).`)
+
+// 7 decimals
+const roundingFactor = 10000000
+
+// Converter holds the lookups (post offices, streets, settlements, municipalities)
+// needed to convert HS (house number) records to OSM tagged features
+type Converter struct {
+	// OSM keys of the converted features, DefaultTaggingProfile by default
+	Profile *TaggingProfile
+	// tags of addresses without a street, MunicipalityPlaceModes by OB_MID or name take precedence
+	PlaceMode              PlaceMode
+	MunicipalityPlaceModes map[string]PlaceMode
+	// rejected and degraded records are written to <RejectionsBasename>.geojson and .csv by Convert, counted only if empty
+	RejectionsBasename string
+
+	source       ShapeSource
+	overridesDir string
+
+	// lookup maps
+	ptCodeMap, ptNameMap, ulNameMap, ulNameDjMap, naNameMap, naNameDjMap, obNameMap map[string]string
+
+	// overrides read for each lookupSources element
+	overrides []*overrides
+	bilingual *bilingualAreas
+	// parsed ptNameMap values by PT_MID
+	postNames map[string]postName
+	// resolved MunicipalityPlaceModes by OB_MID
+	placeModes map[string]PlaceMode
+
+	rejections  *Rejections
+	integrity   *Integrity
+	foreignKeys []foreignKey
+}
+
+// NewConverter opens GURS data in dataDir (downloaded RPE_*.ZIP archives or extracted shapefiles),
+// overrides of abbreviated names are read from <overridesDir>/<COLUMN>.csv
+func NewConverter(dataDir, overridesDir string) (*Converter, error) {
+	source, err := OpenSource(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	return &Converter{Profile: DefaultTaggingProfile(), PlaceMode: DefaultPlaceMode, source: source, overridesDir: overridesDir}, nil
+}
+
+// Close releases the underlying data source
+func (c *Converter) Close() error {
+	return c.source.Close()
+}
+
+// OpenHS opens the HS (house numbers) shapefile, from the data directory if filename is empty
+func (c *Converter) OpenHS(filename string) (*Shapefile, error) {
+	if filename != "" {
+		return OpenShapefile(filename)
+	}
+	return c.source.Open("HS")
+}
+
+// readColumns calls fn with the key and the non-empty value of every row of the named shapefile
+func (c *Converter) readColumns(shapeName string, keyColumnName, valueColumnName string, fn func(key, value string)) error {
+	shapeReader, err := c.source.Open(shapeName)
+	if err != nil {
+		return err
+	}
+	defer shapeReader.Close()
+	shapeFileName := shapeReader.Name
+
+	keyColumnIndex := getColumnIndex(shapeReader.Fields(), keyColumnName)
+	valueColumnIndex := getColumnIndex(shapeReader.Fields(), valueColumnName)
+	if keyColumnIndex < 0 || valueColumnIndex < 0 {
+		return fmt.Errorf("%s: missing column %s or %s in %s", shapeFileName, keyColumnName, valueColumnName, shapeReader.Fields())
+	}
+
+	for shapeReader.Next() {
+		valueUtf := DecodeWindows1250(shapeReader.Attribute(valueColumnIndex))
+		valueUtf = strings.Trim(valueUtf, "\u0000") // trim null characters to remove null strings (when no bilingual name)
+		if len(valueUtf) > 0 {
+			fn(DecodeWindows1250(shapeReader.Attribute(keyColumnIndex)), valueUtf)
+		}
+	}
+
+	if shapeReader.Err() != nil {
+		return fmt.Errorf("error reading %s: %w", shapeFileName, shapeReader.Err())
+	}
+	return nil
+}
+
+// Reads 2 columns from the named shapefile and returns them as a map, with values replaced by overrides
+func (c *Converter) readShapefileToMap(shapeName string, keyColumnName, valueColumnName string) (map[string]string, *overrides, error) {
+	result := make(map[string]string)
+
+	overridesFilename := filepath.Join(c.overridesDir, valueColumnName+".csv")
+	overrides, err := readOverrides(overridesFilename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = c.readColumns(shapeName, keyColumnName, valueColumnName, func(key, valueUtf string) {
+		result[key] = overrides.apply(key, valueUtf)
+		loaded := overrides.lookup(key, valueUtf) != nil
+
+		if isAbbreviated(valueUtf) && !loaded {
+			log.Printf("Possible new abbreviation in %s: %s,%s", valueColumnName, valueUtf, valueUtf)
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return result, overrides, nil
+}
+
+// isAbbreviated returns true if the name has a dot other than in roman numerals, numbers, Dr. and Sv.
+func isAbbreviated(name string) bool {
+	return strings.Contains(reAllowedAbbreviations.ReplaceAllString(name, ""), ".")
+}
+
+func getColumnIndex(fields []shp.Field, columnName string) int {
+
+	for i, v := range fields {
+
+		if v.String() == columnName {
+			return i
+		}
+	}
+
+	return -1
+}
+
+type lookupSource struct {
+	shapeName string
+	keyCol    string
+	valueCol  string
+	mapVar    *map[string]string
+}
+
+func (c *Converter) lookupSources() []lookupSource {
+	return []lookupSource{
+		{"PT", "PT_MID", "PT_ID", &c.ptCodeMap},
+		{"PT", "PT_MID", "PT_UIME", &c.ptNameMap},
+		{"UL", "UL_MID", "UL_UIME", &c.ulNameMap},
+		{"UL", "UL_MID", "UL_DJ", &c.ulNameDjMap},
+		{"NA", "NA_MID", "NA_UIME", &c.naNameMap},
+		{"NA", "NA_MID", "NA_DJ", &c.naNameDjMap},
+		{"OB", "OB_MID", "OB_UIME", &c.obNameMap},
+	}
+}
+
+// ReadLookups reads all needed shapefiles in parallel to maps memory for later use
+func (c *Converter) ReadLookups() error {
+	var wg sync.WaitGroup
+
+	sources := c.lookupSources()
+	errs := make([]error, len(sources))
+	c.overrides = make([]*overrides, len(sources))
+	for i, element := range sources {
+		wg.Add(1)
+		go func(i int, element lookupSource) {
+			*element.mapVar, c.overrides[i], errs[i] = c.readShapefileToMap(element.shapeName, element.keyCol, element.valueCol)
+			wg.Done()
+		}(i, element)
+	}
+
+	var err error
+	c.bilingual, err = readBilingualAreas(filepath.Join(c.overridesDir, BilingualAreasFile))
+
+	wg.Wait()
+	if err := errors.Join(append(errs, err)...); err != nil {
+		return err
+	}
+	c.postNames = c.parsePostNames()
+	return nil
+}
+
+// Rejections returns rejected and degraded records of the last ReadShapefile call
+func (c *Converter) Rejections() *Rejections {
+	return c.rejections
+}
+
+// Integrity returns the referential integrity report of the last ReadShapefile call
+func (c *Converter) Integrity() *Integrity {
+	return c.integrity
+}
+
+// FeatureSink receives converted features with their category ("Ime_občine/Ime_naselja")
+type FeatureSink interface {
+	Add(category string, f *geojson.Feature) error
+}
+
+// featureMap is a FeatureSink keeping all features in memory
+type featureMap map[string]*geojson.FeatureCollection
+
+func (featureCollections featureMap) Add(category string, f *geojson.Feature) error {
+	if _, ok := featureCollections[category]; !ok {
+		// not yet existing
+		featureCollections[category] = geojson.NewFeatureCollection()
+	}
+	featureCollections[category].AddFeature(f)
+	return nil
+}
+
+// ReadShapefile reads the given HS shapefile and returns the geoJson per category ("Ime_občine/Ime_naselja"),
+// use Convert with a Spill for bounded memory usage
+func (c *Converter) ReadShapefile(shapeReader *Shapefile) (map[string]*geojson.FeatureCollection, error) {
+	featureCollections := make(featureMap)
+	if err := c.Convert(shapeReader, featureCollections); err != nil {
+		return nil, err
+	}
+	return featureCollections, nil
+}
+
+// Convert reads the given HS shapefile record by record and passes the features to sink,
+// ReadLookups must be called first
+func (c *Converter) Convert(shapeReader *Shapefile, sink FeatureSink) (err error) {
+	if c.bilingual == nil {
+		return errors.New("lookups not read, call ReadLookups first")
+	}
+
+	// fields from the attribute table (DBF), resolved by name
+	columns, err := resolveHsColumns(shapeReader.Fields())
+	if err != nil {
+		return fmt.Errorf("%s: %w", shapeReader.Name, err)
+	}
+
+	toWGS84, err := coordinateTransformation(shapeReader.Prj)
+	if err != nil {
+		return fmt.Errorf("%s: %w", shapeReader.Name, err)
+	}
+
+	if c.placeModes, err = c.resolvePlaceModes(); err != nil {
+		return err
+	}
+
+	columnNames := make([]string, 0, len(shapeReader.Fields()))
+	for _, field := range shapeReader.Fields() {
+		columnNames = append(columnNames, field.String())
+	}
+	if c.rejections, err = newRejections(columnNames, c.RejectionsBasename); err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := c.rejections.close(); err == nil {
+			err = closeErr
+		}
+	}()
+	c.foreignKeys = c.hsForeignKeys(columns)
+	c.integrity = newIntegrity(c.foreignKeys)
+	c.bilingual.checks = make(map[[3]string]*BilingualCheck)
+	for _, area := range c.bilingual.areas {
+		area.names = 0
+	}
+
+	// loop through all features in the shapefile
+	for shapeReader.Next() {
+		c.rejections.Total++
+
+		f, category, subcategory, err := c.processRecord(shapeReader, columns, toWGS84)
+		if err != nil {
+			return fmt.Errorf("%s: %w", shapeReader.Name, err)
+		}
+
+		if f != nil {
+			if err := sink.Add(category+"/"+subcategory, f); err != nil {
+				return err
+			}
+		}
+	}
+
+	if shapeReader.Err() != nil {
+		return fmt.Errorf("error reading %s: %w", shapeReader.Name, shapeReader.Err())
+	}
+
+	return nil
+}
+
+// processRecord returns the feature and category + subcategory it belongs to (naselje, občina...)
+func (c *Converter) processRecord(shapeReader shp.SequentialReader, columns *hsColumns, toWGS84 transformation) (*geojson.Feature, string, string, error) {
+	_, p := shapeReader.Shape()
+
+	category := "unknown"
+
+	subcategory := "unknown"
+
+	// columns are described in hsSchema
+	hsMid := shapeReader.Attribute(columns.hsMid)
+	reject := func(point []float64, rejected bool, reasons ...Reason) error {
+		attributes := shp.Attributes(shapeReader)
+		for i, attribute := range attributes {
+			attributes[i] = strings.Trim(DecodeWindows1250(attribute), "\u0000")
+		}
+		return c.rejections.add(hsMid, point, attributes, rejected, reasons...)
+	}
+
+	point, validGeometry := toPoint(p, toWGS84)
+
+	if shapeReader.Attribute(columns.status) != "V" {
+		return nil, category, subcategory, reject(point, true, ReasonInvalidStatus)
+	}
+
+	missingLookups := c.integrity.check(hsMid, c.foreignKeys, shapeReader.Attribute)
+
+	labela := shapeReader.Attribute(columns.labela)
+	if labela == "" {
+		return nil, category, subcategory, reject(point, true, ReasonEmptyLabel)
+	}
+
+	if !validGeometry {
+		return nil, category, subcategory, reject(point, true, ReasonInvalidGeometry)
+	}
+
+	// an invalid date only leaves out source:addr:date
+	dateOd, err := isoDate(shapeReader.Attribute(columns.dOd))
+	degraded := missingLookups
+	if err != nil {
+		degraded = append(degraded, ReasonInvalidDate)
+	}
+	if len(degraded) > 0 {
+		if err := reject(point, false, degraded...); err != nil {
+			return nil, category, subcategory, err
+		}
+	}
+
+	lon := point[0]
+	f := geojson.NewPointFeature(point)
+
+	keys := c.Profile.Keys
+	f.SetProperty(keys.Housenumber, DecodeWindows1250(labela))
+
+	// minority language of bilingual names
+	obMid := shapeReader.Attribute(columns.obMid)
+	naMid := shapeReader.Attribute(columns.naMid)
+	area := c.bilingual.area(obMid, c.obNameMap[obMid], naMid, c.naNameMap[naMid])
+	language := func(prefix, column, mid, name string) string {
+		return c.bilingualTag(prefix, area, column, mid, name, c.obNameMap[obMid], c.naNameMap[naMid], lon)
+	}
+
+	c.determineStreetOrPlaceName(shapeReader, columns, f, c.placeTag(obMid), language)
+
+	ptMid := shapeReader.Attribute(columns.ptMid)
+	f.SetProperty(keys.Postcode, c.ptCodeMap[ptMid])
+
+	post := c.postNames[ptMid]
+	f.SetProperty(keys.City, post.name)
+	switch {
+	case post.minority != "":
+		c.setName(f, keys.City, post.slovenian, post.minority, language(keys.City, "PT_UIME", ptMid, post.minority))
+	case post.odd:
+		c.bilingual.report(bilingualPostParts, "PT_UIME", ptMid, post.name, c.obNameMap[obMid], c.naNameMap[naMid])
+	case post.hyphenated && area != nil:
+		c.bilingual.report(bilingualPostHyphen, "PT_UIME", ptMid, post.name, c.obNameMap[obMid], c.naNameMap[naMid])
+	}
+
+	c.setVillageIfNeeded(shapeReader, columns, f, language)
+
+	if dateOd != "" {
+		setOptional(f, keys.SourceDate, dateOd)
+	}
+
+	setOptional(f, keys.Source, c.Profile.SourceValue)
+
+	f.SetProperty(keys.Ref, hsMid)
+
+	// prepare a nice category "Ime_občine/Ime_naselja"
+	category = strings.Replace(c.obNameMap[obMid], " ", "_", -1)
+	subcategory = strings.Replace(c.naNameMap[naMid], " ", "_", -1)
+
+	return f, category, subcategory, nil
+}
+
+// isoDate returns the DBF date (YYYYMMDD) in the ISO YYYY-MM-DD format
+func isoDate(dbfDate string) (string, error) {
+	date, err := time.Parse("20060102", dbfDate)
+	if err != nil || len(dbfDate) != len("20060102") {
+		return "", fmt.Errorf("invalid D_OD date %q", dbfDate)
+	}
+	return date.Format(time.DateOnly), nil
+}
+
+// Slovenia with some margin, points outside are invalid
+const (
+	minLon, maxLon = 13.0, 17.0
+	minLat, maxLat = 45.0, 47.5
+)
+
+// toPoint returns rounded WGS84 coordinates of the shape and whether it is a valid point in Slovenia
+func toPoint(p shp.Shape, toWGS84 transformation) ([]float64, bool) {
+	switch p.(type) {
+	case *shp.Point, *shp.PointZ, *shp.PointM:
+	default:
+		return nil, false
+	}
+
+	bb := p.BBox()
+	// prepare rounded coordinates:
+	lon, lat := toWGS84(bb.MinX, bb.MinY)
+	if math.IsNaN(lon) || math.IsNaN(lat) {
+		return nil, false
+	}
+	point := []float64{round(lon), round(lat)}
+
+	return point, lon >= minLon && lon <= maxLon && lat >= minLat && lat <= maxLat
+}
+
+// hsForeignKeys returns HS columns referencing lookup tables
+func (c *Converter) hsForeignKeys(columns *hsColumns) []foreignKey {
+	return []foreignKey{
+		// houses in villages without streets have no UL_MID
+		{table: "UL", column: columns.ulMid, lookup: c.ulNameMap, reason: ReasonMissingStreet, optional: true},
+		{table: "NA", column: columns.naMid, lookup: c.naNameMap, reason: ReasonMissingSettlement},
+		{table: "PT", column: columns.ptMid, lookup: c.ptCodeMap, reason: ReasonMissingPost},
+		{table: "OB", column: columns.obMid, lookup: c.obNameMap, reason: ReasonMissingMunicipality},
+	}
+}
+
+func round(number float64) float64 {
+	return math.Round(number*roundingFactor) / roundingFactor
+}
+
+// languageTag returns the tag with the language postfix for the minority language name from the column and MID
+type languageTag func(prefix, column, mid, name string) string
+
+func (c *Converter) determineStreetOrPlaceName(shapeReader shp.SequentialReader, columns *hsColumns, f *geojson.Feature, place string, language languageTag) {
+	ulMid := shapeReader.Attribute(columns.ulMid)
+	if ulName, streetNameExists := c.ulNameMap[ulMid]; streetNameExists {
+		// street name exists
+
+		if ulNameDj, bilingualStreetNameExists := c.ulNameDjMap[ulMid]; bilingualStreetNameExists && ulNameDj != ulName {
+			// bilingual street name exists
+			street := c.Profile.Keys.Street
+			c.setName(f, street, ulName, ulNameDj, language(street, "UL_DJ", ulMid, ulNameDj))
+		} else {
+			// only slovenian name
+			f.SetProperty(c.Profile.Keys.Street, ulName)
+		}
+	} else {
+		// no street name, only place
+		naMid := shapeReader.Attribute(columns.naMid)
+		naName := c.naNameMap[naMid]
+
+		if naNameDj, bilingualPlaceNameExists := c.naNameDjMap[naMid]; bilingualPlaceNameExists && naNameDj != naName {
+			// bilingual place name exists
+			c.setName(f, place, naName, naNameDj, language(place, "NA_DJ", naMid, naNameDj))
+		} else {
+			// only slovenian name
+			f.SetProperty(place, naName)
+		}
+	}
+}
+
+func (c *Converter) setVillageIfNeeded(shapeReader shp.SequentialReader, columns *hsColumns, f *geojson.Feature, language languageTag) {
+	ulMid := shapeReader.Attribute(columns.ulMid)
+
+	naMid := shapeReader.Attribute(columns.naMid)
+	naName := c.naNameMap[naMid]
+
+	ptMid := shapeReader.Attribute(columns.ptMid)
+	ptName := c.ptNameMap[ptMid]
+
+	if ulName, streetNameExists := c.ulNameMap[ulMid]; streetNameExists {
+		// street name exists
+
+		if (!strings.HasPrefix(ptName, naName)) && (ulName != naName) {
+			// village name not in post or street name, should add addr:village tag
+
+			if naNameDj, bilingualPlaceNameExists := c.naNameDjMap[naMid]; bilingualPlaceNameExists && naNameDj != naName {
+				// bilingual place name exists
+				village := c.Profile.Keys.Village
+				c.setName(f, village, naName, naNameDj, language(village, "NA_DJ", naMid, naNameDj))
+			} else {
+				// only slovenian name
+				setOptional(f, c.Profile.Keys.Village, naName)
+			}
+		}
+	}
+}
+
+// setName sets key to "slovenian / minority" of a bilingual name, with Languages of the profile also key:sl
+// and minorityKey to the separate names; an empty optional key is left out
+func (c *Converter) setName(f *geojson.Feature, key, slovenian, minority, minorityKey string) {
+	if key == "" {
+		return
+	}
+	f.SetProperty(key, slovenian+bilingualSeparator+minority)
+	if c.Profile.Languages {
+		f.SetProperty(key+tagLangPostfixSlovenian, slovenian)
+		f.SetProperty(minorityKey, minority)
+	}
+}
+
+// setOptional sets the key of an optional value, unless it is left out by an empty key
+func setOptional(f *geojson.Feature, key string, value interface{}) {
+	if key != "" {
+		f.SetProperty(key, value)
+	}
+}
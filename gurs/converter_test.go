@@ -0,0 +1,254 @@
+package gurs
+
+import (
+	"path/filepath"
+	"testing"
+
+	shp "github.com/jonas-p/go-shp"
+	geojson "github.com/paulmach/go.geojson"
+)
+
+// real GURS data, needed for tests and benchmarks not running in short mode
+const (
+	testDataDir      = "../data/downloaded/"
+	testOverridesDir = "../overrides/"
+)
+
+func BenchmarkReadShapefile(b *testing.B) {
+	if testing.Short() {
+		b.Skip("skipping test in short mode.")
+	}
+	b.ReportAllocs()
+	converter := newTestConverter(b, testDataDir)
+	hs := openTestHS(b, converter)
+	b.ResetTimer()
+	_, _ = converter.ReadShapefile(hs)
+}
+
+func BenchmarkSort(b *testing.B) {
+	if testing.Short() {
+		b.Skip("skipping test in short mode.")
+	}
+	b.ReportAllocs()
+	converter := newTestConverter(b, testDataDir)
+	featureCollections, err := converter.ReadShapefile(openTestHS(b, converter))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for _, c := range featureCollections {
+		SortFeatureCollection(*c, DefaultTaggingProfile(), DefaultSortOrder)
+	}
+}
+
+func BenchmarkReadLookups(b *testing.B) {
+	if testing.Short() {
+		b.Skip("skipping test in short mode.")
+	}
+	b.ReportAllocs()
+	converter := newTestConverter(b, testDataDir)
+	b.ResetTimer()
+
+	_ = converter.ReadLookups()
+}
+
+func TestReadLookups(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+	converter := newTestConverter(t, testDataDir)
+
+	// just check the length ranges
+	assertBetween(t, len(converter.ptCodeMap), 400, 500)
+	assertBetween(t, len(converter.ptNameMap), 400, 500)
+	assertBetween(t, len(converter.ulNameMap), 10000, 11000)
+	assertBetween(t, len(converter.ulNameDjMap), 600, 700)
+	assertBetween(t, len(converter.naNameMap), 6000, 7000)
+	assertBetween(t, len(converter.naNameDjMap), 50, 60)
+	assertBetween(t, len(converter.obNameMap), 210, 220)
+}
+
+// hsTestRecord is one row of the HS test shapefile
+type hsTestRecord struct {
+	hsMid, labela, ulMid, naMid, obMid, ptMid, status string
+	easting, northing                                 float64
+}
+
+// writeTestData writes a small extracted RPE dataset (PT, UL, NA, OB and HS) into dir
+func writeTestData(t testing.TB, dir string, records []hsTestRecord) {
+	t.Helper()
+
+	writeTestPT(t, dir)
+	writeTestShapefile(t, dir, "UL",
+		[]shp.Field{shp.NumberField("UL_MID", 8), shp.StringField("UL_UIME", 40), shp.StringField("UL_DJ", 40)},
+		[]shp.Point{{}, {}, {}},
+		[][]string{
+			{"101", "Slovenska cesta", ""},
+			{"102", "Tartinijev trg", "Piazza Giuseppe Tartini"},
+			{"103", EncodeWindows1250("Cesta slov. kmečkih uporov"), ""},
+		},
+	)
+	writeTestShapefile(t, dir, "NA",
+		[]shp.Field{shp.NumberField("NA_MID", 8), shp.StringField("NA_UIME", 40), shp.StringField("NA_DJ", 40)},
+		[]shp.Point{{}, {}, {}},
+		[][]string{
+			{"201", "Ljubljana", ""},
+			{"202", "Piran", "Pirano"},
+			{"203", "Zgornja vas", ""},
+		},
+	)
+	writeTestShapefile(t, dir, "OB",
+		[]shp.Field{shp.NumberField("OB_MID", 8), shp.StringField("OB_UIME", 40)},
+		[]shp.Point{{}, {}},
+		[][]string{
+			{"301", "Ljubljana"},
+			{"302", "Piran"},
+		},
+	)
+
+	points := make([]shp.Point, 0, len(records))
+	rows := make([][]string, 0, len(records))
+	for _, r := range records {
+		points = append(points, shp.Point{X: r.easting, Y: r.northing})
+		rows = append(rows, []string{"HS", r.hsMid, "", "", r.labela, r.ulMid, r.naMid, r.obMid, r.ptMid, "", "20240115", "20240116", r.status, "", ""})
+	}
+	writeTestShapefile(t, dir, "HS", schemaFields(hsSchema), points, rows)
+}
+
+// testRecords covers streets, bilingual names and settlements without streets
+var testRecords = []hsTestRecord{
+	{"1001", "12", "101", "201", "301", "11026975", "V", 461755.9762, 101252.0983},
+	{"1002", "3a", "102", "202", "302", "11027123", "V", 398494.7924, 46387.3150},
+	{"1003", "5", "0", "203", "301", "11026975", "V", 462000, 101500},
+	{"1004", "7", "103", "203", "301", "11026975", "V", 462100, 101600},
+	{"1005", "9", "101", "201", "301", "11026975", "N", 461800, 101300},
+}
+
+func newTestConverter(tb testing.TB, dataDir string) *Converter {
+	tb.Helper()
+
+	converter, err := NewConverter(dataDir, testOverridesDir)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { converter.Close() })
+
+	if err := converter.ReadLookups(); err != nil {
+		tb.Fatal(err)
+	}
+	return converter
+}
+
+func openTestHS(tb testing.TB, converter *Converter) *Shapefile {
+	tb.Helper()
+
+	hs, err := converter.OpenHS("")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { hs.Close() })
+	return hs
+}
+
+// convertTestData converts the records and returns the converter, features per category and features by ref:gurs:hs_mid,
+// rejections are written to converter.RejectionsBasename in a temporary directory
+func convertTestData(t *testing.T, records []hsTestRecord) (*Converter, map[string]*geojson.FeatureCollection, map[string]*geojson.Feature) {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeTestData(t, dir, records)
+
+	converter := newTestConverter(t, dir)
+	converter.RejectionsBasename = filepath.Join(t.TempDir(), "rejected")
+	featureCollections, err := converter.ReadShapefile(openTestHS(t, converter))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	features := make(map[string]*geojson.Feature)
+	for _, featureCollection := range featureCollections {
+		for _, f := range featureCollection.Features {
+			features[f.Properties[tagRef].(string)] = f
+		}
+	}
+	return converter, featureCollections, features
+}
+
+func TestConverter(t *testing.T) {
+	_, featureCollections, features := convertTestData(t, testRecords)
+
+	assertEqual(t, len(featureCollections), 3)
+	assertEqual(t, len(featureCollections["Ljubljana/Ljubljana"].Features), 1)
+	assertEqual(t, len(featureCollections["Ljubljana/Zgornja_vas"].Features), 2)
+	assertEqual(t, len(featureCollections["Piran/Piran"].Features), 1)
+	assertEqual(t, len(features), 4)
+
+	ljubljana := features["1001"]
+	assertEqual(t, ljubljana.Geometry.Point[0], 14.5058)
+	assertEqual(t, ljubljana.Geometry.Point[1], 46.05)
+	assertEqual(t, ljubljana.Properties[tagHousenumber], "12")
+	assertEqual(t, ljubljana.Properties[tagStreet], "Slovenska cesta")
+	assertEqual(t, ljubljana.Properties[tagPostCode], "1000")
+	assertEqual(t, ljubljana.Properties[tagCity], "Ljubljana")
+	assertEqual(t, ljubljana.Properties[tagSourceDate], "2024-01-15")
+	assertEqual(t, ljubljana.Properties[tagSource], "GURS")
+	assertEqual(t, ljubljana.Properties[tagVillage], nil)
+
+	piran := features["1002"]
+	assertEqual(t, piran.Properties[tagStreet], "Tartinijev trg / Piazza Giuseppe Tartini")
+	assertEqual(t, piran.Properties[tagStreet+":sl"], "Tartinijev trg")
+	assertEqual(t, piran.Properties[tagStreet+":it"], "Piazza Giuseppe Tartini")
+	assertEqual(t, piran.Properties[tagCity], "Piran / Pirano")
+	assertEqual(t, piran.Properties[tagCity+":sl"], "Piran")
+	assertEqual(t, piran.Properties[tagCity+":it"], "Pirano")
+	assertEqual(t, piran.Properties[tagPostCode], "6330")
+
+	// no street, settlement name used instead
+	assertEqual(t, features["1003"].Properties[tagStreet], "Zgornja vas")
+	assertEqual(t, features["1003"].Properties[tagPlace], nil)
+	assertEqual(t, features["1003"].Properties[tagVillage], nil)
+
+	// abbreviation expanded by overrides, village not in post or street name
+	assertEqual(t, features["1004"].Properties[tagStreet], "Cesta slovenskih kmečkih uporov")
+	assertEqual(t, features["1004"].Properties[tagVillage], "Zgornja vas")
+}
+
+func TestIsoDate(t *testing.T) {
+	date, err := isoDate("20240115")
+	assertEqual(t, date, "2024-01-15")
+	assertEqual(t, err, nil)
+	for _, invalid := range []string{"", "2024", "202401151", "2024XX15", "20241315"} {
+		if _, err := isoDate(invalid); err == nil {
+			t.Errorf("%q should be an invalid date", invalid)
+		}
+	}
+}
+
+func TestConvertWithoutLookups(t *testing.T) {
+	dir := t.TempDir()
+	writeTestData(t, dir, testRecords)
+	converter, err := NewConverter(dir, testOverridesDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer converter.Close()
+
+	if _, err := converter.ReadShapefile(openTestHS(t, converter)); err == nil {
+		t.Error("converting without ReadLookups should fail")
+	}
+}
+
+// utility methods
+func assertEqual(t *testing.T, testedValue, expected interface{}) {
+	t.Helper()
+	if testedValue != expected {
+		t.Errorf("\"%v\" != \"%v\"", testedValue, expected)
+	}
+}
+
+func assertBetween(t *testing.T, testedValue, lowBound, upperBound int) {
+	if testedValue <= lowBound || testedValue >= upperBound {
+		t.Errorf("%d should be between %d and %d", testedValue, lowBound, upperBound)
+	}
+}
@@ -0,0 +1,139 @@
+package gurs
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// PortalMachine is the host of the portal in .netrc files
+const PortalMachine = "egp.gu.gov.si"
+
+// environment variables with the portal credentials
+const (
+	UsernameEnv = "GURS_USERNAME"
+	PasswordEnv = "GURS_PASSWORD"
+)
+
+// Credentials for the portal login, the password is never printed
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// String hides the password
+func (c Credentials) String() string {
+	return c.Username + ":********"
+}
+
+// GoString hides the password from %#v too
+func (c Credentials) GoString() string {
+	return c.String()
+}
+
+// CredentialsFromEnv returns credentials from GURS_USERNAME and GURS_PASSWORD, false if any is not set
+func CredentialsFromEnv() (Credentials, bool) {
+	c := Credentials{Username: os.Getenv(UsernameEnv), Password: os.Getenv(PasswordEnv)}
+	return c, c.Username != "" && c.Password != ""
+}
+
+// CredentialsFromNetrc returns login and password of machine (or default) in a .netrc file,
+// false if the file or the machine is missing; files readable or writable by others are refused
+func CredentialsFromNetrc(filename, machine string) (Credentials, bool, error) {
+	info, err := os.Stat(filename)
+	if os.IsNotExist(err) {
+		return Credentials{}, false, nil
+	} else if err != nil {
+		return Credentials{}, false, err
+	}
+	if info.Mode().Perm()&0006 != 0 {
+		return Credentials{}, false, fmt.Errorf("%s is accessible by other users (%v), run chmod 600 %s", filename, info.Mode().Perm(), filename)
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return Credentials{}, false, err
+	}
+
+	// tokens: machine <name> login <username> password <password>, default applies to all machines
+	var found, fallback Credentials
+	var current *Credentials
+	var foundMachine, foundDefault bool
+	fields := strings.Fields(string(content))
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "machine":
+			current = nil
+			if i+1 < len(fields) && fields[i+1] == machine && !foundMachine {
+				current, foundMachine = &found, true
+			}
+			i++
+		case "default":
+			current = nil
+			if !foundDefault {
+				current, foundDefault = &fallback, true
+			}
+		case "login", "password", "account":
+			if i+1 >= len(fields) {
+				return Credentials{}, false, fmt.Errorf("%s: %s without a value", filename, fields[i])
+			}
+			if current != nil && fields[i] == "login" {
+				current.Username = fields[i+1]
+			} else if current != nil && fields[i] == "password" {
+				current.Password = fields[i+1]
+			}
+			i++
+		case "macdef":
+			// macro definitions end with an empty line, not supported
+			return Credentials{}, false, fmt.Errorf("%s: macdef is not supported", filename)
+		}
+	}
+	if foundMachine {
+		return found, true, nil
+	}
+	return fallback, foundDefault, nil
+}
+
+// CredentialsFromCommand runs the command (without a shell, eg. "pass show egp.gu.gov.si"),
+// its first output line is the password and a "login: <username>" or "username: <username>" line
+// the username, as in pass files; username is used if there is no such line
+func CredentialsFromCommand(command, username string) (Credentials, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return Credentials{}, errors.New("empty credentials command")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	// for gpg or keyring prompts
+	cmd.Stdin, cmd.Stderr = os.Stdin, os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		// output is not included, it may hold the password
+		return Credentials{}, fmt.Errorf("credentials command %s: %w", args[0], err)
+	}
+
+	c := Credentials{Username: username}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for first := true; scanner.Scan(); first = false {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if first {
+			c.Password = line
+			continue
+		}
+		if key, value, found := strings.Cut(line, ":"); found {
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "login", "username", "user":
+				c.Username = strings.TrimSpace(value)
+			}
+		}
+	}
+	if c.Password == "" {
+		return Credentials{}, fmt.Errorf("credentials command %s: no password in the first line", args[0])
+	}
+	if c.Username == "" {
+		return Credentials{}, fmt.Errorf("credentials command %s: no login line and no username given", args[0])
+	}
+	return c, nil
+}
@@ -0,0 +1,90 @@
+package gurs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCredentialsFromNetrc(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ".netrc")
+	netrc := "machine example.com login other password x\n" +
+		"machine egp.gu.gov.si\n  login user\n  password secret\n" +
+		"default login anonymous password guest\n"
+	if err := os.WriteFile(filename, []byte(netrc), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	credentials, found, err := CredentialsFromNetrc(filename, PortalMachine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, found, true)
+	assertEqual(t, credentials.Username, "user")
+	assertEqual(t, credentials.Password, "secret")
+
+	credentials, found, err = CredentialsFromNetrc(filename, "other.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, found, true)
+	assertEqual(t, credentials.Username, "anonymous")
+
+	_, found, err = CredentialsFromNetrc(filepath.Join(t.TempDir(), "missing"), PortalMachine)
+	assertEqual(t, found, false)
+	assertEqual(t, err, nil)
+
+	// readable by everyone
+	if err := os.Chmod(filename, 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, found, err = CredentialsFromNetrc(filename, PortalMachine)
+	assertEqual(t, found, false)
+	assertEqual(t, strings.Contains(err.Error(), "chmod 600"), true)
+	assertEqual(t, strings.Contains(err.Error(), "secret"), false)
+}
+
+func TestCredentialsFromEnv(t *testing.T) {
+	t.Setenv(UsernameEnv, "user")
+	t.Setenv(PasswordEnv, "")
+	_, found := CredentialsFromEnv()
+	assertEqual(t, found, false)
+
+	t.Setenv(PasswordEnv, "secret")
+	credentials, found := CredentialsFromEnv()
+	assertEqual(t, found, true)
+	assertEqual(t, credentials.Password, "secret")
+
+	// never printed
+	assertEqual(t, fmt.Sprintf("%v %+v %#v %s", credentials, credentials, credentials, credentials),
+		"user:******** user:******** user:******** user:********")
+}
+
+func TestCredentialsFromCommand(t *testing.T) {
+	// like "pass show egp.gu.gov.si"
+	script := filepath.Join(t.TempDir(), "pass")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nprintf 'secret\\nlogin: user\\nurl: https://egp.gu.gov.si/\\n'\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	credentials, err := CredentialsFromCommand(script+" show egp.gu.gov.si", "fallback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, credentials.Username, "user")
+	assertEqual(t, credentials.Password, "secret")
+
+	// secret-tool prints only the password
+	credentials, err = CredentialsFromCommand("echo secret", "fallback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, credentials.Username, "fallback")
+	assertEqual(t, credentials.Password, "secret")
+
+	_, err = CredentialsFromCommand("echo secret", "")
+	assertEqual(t, err.Error(), "credentials command echo: no login line and no username given")
+	_, err = CredentialsFromCommand("false", "user")
+	assertEqual(t, err.Error(), "credentials command false: exit status 1")
+}
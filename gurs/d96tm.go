@@ -0,0 +1,244 @@
+package gurs
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+)
+
+// ellipsoid defines the reference ellipsoid by its semi-major axis and flattening
+type ellipsoid struct {
+	a, f float64
+}
+
+var (
+	ellipsoidGRS80 = ellipsoid{a: 6378137, f: 1 / 298.257222101}
+	ellipsoidWGS84 = ellipsoid{a: 6378137, f: 1 / 298.257223563}
+)
+
+func (el ellipsoid) e2() float64 {
+	return el.f * (2 - el.f)
+}
+
+// helmert holds 7-parameter (position vector) datum transformation parameters:
+// translations in meters, rotations in arc seconds and scale in ppm
+type helmert struct {
+	tx, ty, tz, rx, ry, rz, s float64
+}
+
+// transverseMercator is a Transverse Mercator projection on the given ellipsoid,
+// implemented with 6th order Krüger series as described in
+// Karney (2011): Transverse Mercator with an accuracy of a few nanometers, https://arxiv.org/abs/1002.1417
+type transverseMercator struct {
+	ellipsoid
+	lon0, k0, falseEasting, falseNorthing float64
+	// datum transformation to WGS84
+	toWGS84 helmert
+
+	e, bigA     float64
+	alpha, beta [6]float64
+}
+
+// newTransverseMercator precomputes series coefficients for the given projection parameters,
+// lon0 is in degrees, latitude of origin is always the equator
+func newTransverseMercator(el ellipsoid, lon0, k0, falseEasting, falseNorthing float64, toWGS84 helmert) *transverseMercator {
+	tm := &transverseMercator{ellipsoid: el, lon0: lon0, k0: k0, falseEasting: falseEasting, falseNorthing: falseNorthing, toWGS84: toWGS84}
+
+	n := el.f / (2 - el.f)
+	n2 := n * n
+	n3 := n2 * n
+	n4 := n3 * n
+	n5 := n4 * n
+	n6 := n5 * n
+
+	tm.e = math.Sqrt(el.e2())
+	tm.bigA = el.a / (1 + n) * (1 + n2/4 + n4/64 + n6/256)
+
+	tm.alpha = [6]float64{
+		n/2 - 2*n2/3 + 5*n3/16 + 41*n4/180 - 127*n5/288 + 7891*n6/37800,
+		13*n2/48 - 3*n3/5 + 557*n4/1440 + 281*n5/630 - 1983433*n6/1935360,
+		61*n3/240 - 103*n4/140 + 15061*n5/26880 + 167603*n6/181440,
+		49561*n4/161280 - 179*n5/168 + 6601661*n6/7257600,
+		34729*n5/80640 - 3418889*n6/1995840,
+		212378941 * n6 / 319334400,
+	}
+	tm.beta = [6]float64{
+		n/2 - 2*n2/3 + 37*n3/96 - n4/360 - 81*n5/512 + 96199*n6/604800,
+		n2/48 + n3/15 - 437*n4/1440 + 46*n5/105 - 1118711*n6/3870720,
+		17*n3/480 - 37*n4/840 - 209*n5/4480 + 5569*n6/90720,
+		4397*n4/161280 - 11*n5/504 - 830251*n6/7257600,
+		4583*n5/161280 - 108847*n6/3991680,
+		20648693 * n6 / 638668800,
+	}
+
+	return tm
+}
+
+// D96/TM (EPSG:3794), the Slovenian national grid used by GURS.
+// D96 is the Slovenian realisation of ETRS89, which coincides with WGS84 to within a meter
+// (EPSG:1149 transformation, all parameters zero), so only the ellipsoids differ.
+var d96tm = newTransverseMercator(ellipsoidGRS80, 15, 0.9999, 500000, -5000000, helmert{})
+
+// ToWGS84 converts projected easting/northing to WGS84 longitude and latitude in degrees
+func (tm *transverseMercator) ToWGS84(easting, northing float64) (lon, lat float64) {
+	lon, lat = tm.Inverse(easting, northing)
+	return tm.datumToWGS84(lon, lat)
+}
+
+// FromWGS84 converts WGS84 longitude and latitude in degrees to projected easting/northing
+func (tm *transverseMercator) FromWGS84(lon, lat float64) (easting, northing float64) {
+	lon, lat = tm.datumFromWGS84(lon, lat)
+	return tm.Forward(lon, lat)
+}
+
+// Inverse converts easting/northing to longitude and latitude in degrees on the projection's own datum
+func (tm *transverseMercator) Inverse(easting, northing float64) (lon, lat float64) {
+	xi := (northing - tm.falseNorthing) / (tm.k0 * tm.bigA)
+	eta := (easting - tm.falseEasting) / (tm.k0 * tm.bigA)
+
+	xi1, eta1 := xi, eta
+	for j, b := range tm.beta {
+		k := 2 * float64(j+1)
+		xi1 -= b * math.Sin(k*xi) * math.Cosh(k*eta)
+		eta1 -= b * math.Cos(k*xi) * math.Sinh(k*eta)
+	}
+
+	sinhEta1 := math.Sinh(eta1)
+	cosXi1 := math.Cos(xi1)
+	tau1 := math.Sin(xi1) / math.Hypot(sinhEta1, cosXi1)
+	lambda := math.Atan2(sinhEta1, cosXi1)
+
+	// solve tau from tau' with Newton's method, converges in 2-3 iterations
+	e2 := tm.e2()
+	tau := tau1
+	for i := 0; i < 10; i++ {
+		tauI := tm.conformalTau(tau)
+		delta := (tau1 - tauI) / math.Sqrt(1+tauI*tauI) *
+			(1 + (1-e2)*tau*tau) / ((1 - e2) * math.Sqrt(1+tau*tau))
+		tau += delta
+		if math.Abs(delta) < 1e-14 {
+			break
+		}
+	}
+
+	return tm.lon0 + radiansToDegrees(lambda), radiansToDegrees(math.Atan(tau))
+}
+
+// Forward converts longitude and latitude in degrees on the projection's own datum to easting/northing
+func (tm *transverseMercator) Forward(lon, lat float64) (easting, northing float64) {
+	lambda := degreesToRadians(lon - tm.lon0)
+	tau1 := tm.conformalTau(math.Tan(degreesToRadians(lat)))
+
+	cosLambda := math.Cos(lambda)
+	xi1 := math.Atan2(tau1, cosLambda)
+	eta1 := math.Asinh(math.Sin(lambda) / math.Hypot(tau1, cosLambda))
+
+	xi, eta := xi1, eta1
+	for j, a := range tm.alpha {
+		k := 2 * float64(j+1)
+		xi += a * math.Sin(k*xi1) * math.Cosh(k*eta1)
+		eta += a * math.Cos(k*xi1) * math.Sinh(k*eta1)
+	}
+
+	return tm.falseEasting + tm.k0*tm.bigA*eta, tm.falseNorthing + tm.k0*tm.bigA*xi
+}
+
+// conformalTau returns tangent of conformal latitude for the given tangent of geodetic latitude
+func (tm *transverseMercator) conformalTau(tau float64) float64 {
+	sigma := math.Sinh(tm.e * math.Atanh(tm.e*tau/math.Sqrt(1+tau*tau)))
+	return tau*math.Sqrt(1+sigma*sigma) - sigma*math.Sqrt(1+tau*tau)
+}
+
+func (tm *transverseMercator) datumToWGS84(lon, lat float64) (float64, float64) {
+	x, y, z := toGeocentric(tm.ellipsoid, lon, lat)
+	x, y, z = tm.toWGS84.apply(x, y, z, 1)
+	return fromGeocentric(ellipsoidWGS84, x, y, z)
+}
+
+func (tm *transverseMercator) datumFromWGS84(lon, lat float64) (float64, float64) {
+	x, y, z := toGeocentric(ellipsoidWGS84, lon, lat)
+	x, y, z = tm.toWGS84.apply(x, y, z, -1)
+	return fromGeocentric(tm.ellipsoid, x, y, z)
+}
+
+// apply transforms geocentric coordinates, direction -1 applies the (small angle) reverse transformation
+func (h helmert) apply(x, y, z float64, direction float64) (float64, float64, float64) {
+	const arcSecond = math.Pi / (180 * 3600)
+	rx, ry, rz := direction*h.rx*arcSecond, direction*h.ry*arcSecond, direction*h.rz*arcSecond
+	scale := 1 + direction*h.s/1e6
+
+	return direction*h.tx + scale*(x-rz*y+ry*z),
+		direction*h.ty + scale*(rz*x+y-rx*z),
+		direction*h.tz + scale*(-ry*x+rx*y+z)
+}
+
+// toGeocentric converts longitude and latitude in degrees (at ellipsoid height 0) to geocentric X, Y, Z
+func toGeocentric(el ellipsoid, lon, lat float64) (x, y, z float64) {
+	phi, lambda := degreesToRadians(lat), degreesToRadians(lon)
+	e2 := el.e2()
+	sinPhi := math.Sin(phi)
+	nu := el.a / math.Sqrt(1-e2*sinPhi*sinPhi)
+
+	return nu * math.Cos(phi) * math.Cos(lambda), nu * math.Cos(phi) * math.Sin(lambda), nu * (1 - e2) * sinPhi
+}
+
+// fromGeocentric converts geocentric X, Y, Z to longitude and latitude in degrees, ignoring height
+func fromGeocentric(el ellipsoid, x, y, z float64) (lon, lat float64) {
+	e2 := el.e2()
+	p := math.Hypot(x, y)
+	phi := math.Atan2(z, p*(1-e2))
+	for i := 0; i < 10; i++ {
+		sinPhi := math.Sin(phi)
+		nu := el.a / math.Sqrt(1-e2*sinPhi*sinPhi)
+		next := math.Atan2(z+e2*nu*sinPhi, p)
+		if math.Abs(next-phi) < 1e-15 {
+			phi = next
+			break
+		}
+		phi = next
+	}
+
+	return radiansToDegrees(math.Atan2(y, x)), radiansToDegrees(phi)
+}
+
+func degreesToRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func radiansToDegrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
+
+// transformation converts source x/y coordinates to WGS84 longitude and latitude
+type transformation func(x, y float64) (lon, lat float64)
+
+func identity(x, y float64) (float64, float64) {
+	return x, y
+}
+
+// coordinateTransformation picks the transformation to WGS84 based on the shapefile's .prj content,
+// original GURS exports are in D96/TM, already reprojected files (eg. by ogr2ogr) in geographic coordinates
+func coordinateTransformation(prj string) (transformation, error) {
+	if strings.TrimSpace(prj) == "" {
+		log.Printf("No .prj found, assuming D96/TM")
+		return d96tm.ToWGS84, nil
+	}
+
+	return transformationForWKT(prj)
+}
+
+func transformationForWKT(wkt string) (transformation, error) {
+	wkt = strings.TrimSpace(wkt)
+	switch {
+	case strings.HasPrefix(wkt, "GEOGCS[") || strings.HasPrefix(wkt, "GEOGCRS["):
+		return identity, nil
+	case strings.HasPrefix(wkt, "PROJCS[") || strings.HasPrefix(wkt, "PROJCRS["):
+		isD96 := strings.Contains(wkt, "D96") || strings.Contains(wkt, "1996")
+		if isD96 && strings.Contains(strings.ReplaceAll(strings.ToLower(wkt), " ", "_"), "transverse_mercator") {
+			return d96tm.ToWGS84, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported coordinate system, only D96/TM (EPSG:3794) and geographic WGS84 are supported: %.60s", wkt)
+}
@@ -0,0 +1,91 @@
+package gurs
+
+import (
+	"math"
+	"testing"
+)
+
+// control points: WGS84 longitude, latitude and their D96/TM easting, northing
+// on the central meridian northings are k0 * meridian arc length - 5000000, computed by numerical integration,
+// elsewhere they are cross-checked with the independent USGS series (Snyder 1987, Map Projections - A Working Manual)
+var d96tmControlPoints = [...]struct {
+	name              string
+	lon, lat          float64
+	easting, northing float64
+}{
+	{"central meridian 45.5", 15, 45.5, 500000, 40008.6581},
+	{"central meridian 46.0", 15, 46, 500000, 95576.3177},
+	{"central meridian 46.5", 15, 46.5, 500000, 151148.8607},
+	{"Ljubljana", 14.5058, 46.05, 461755.9762, 101252.0983},
+	{"Piran", 13.7, 45.55, 398494.7924, 46387.3150},
+	{"Lendava", 16.2, 46.65, 591851.9137, 168521.0730},
+	{"Bovec", 13.38, 46.38, 375384.5304, 139086.5284},
+}
+
+func TestD96TMToWGS84(t *testing.T) {
+	// 1e-7 degrees is about 1 cm, the GRS80/WGS84 ellipsoid difference is ~0.1 mm
+	const tolerance = 1e-7
+	for _, p := range d96tmControlPoints {
+		lon, lat := d96tm.ToWGS84(p.easting, p.northing)
+		if math.Abs(lon-p.lon) > tolerance || math.Abs(lat-p.lat) > tolerance {
+			t.Errorf("%s: %.4f, %.4f gave %.9f, %.9f instead of %.9f, %.9f", p.name, p.easting, p.northing, lon, lat, p.lon, p.lat)
+		}
+	}
+}
+
+func TestD96TMFromWGS84(t *testing.T) {
+	// 1 mm
+	const tolerance = 0.001
+	for _, p := range d96tmControlPoints {
+		easting, northing := d96tm.FromWGS84(p.lon, p.lat)
+		if math.Abs(easting-p.easting) > tolerance || math.Abs(northing-p.northing) > tolerance {
+			t.Errorf("%s: %.9f, %.9f gave %.4f, %.4f instead of %.4f, %.4f", p.name, p.lon, p.lat, easting, northing, p.easting, p.northing)
+		}
+	}
+}
+
+func TestD96TMRoundTrip(t *testing.T) {
+	// whole bounding box of Slovenia
+	for easting := 370000.0; easting <= 630000; easting += 10000 {
+		for northing := 30000.0; northing <= 200000; northing += 10000 {
+			lon, lat := d96tm.ToWGS84(easting, northing)
+			e, n := d96tm.FromWGS84(lon, lat)
+			if math.Abs(e-easting) > 1e-6 || math.Abs(n-northing) > 1e-6 {
+				t.Errorf("%.0f, %.0f -> %.9f, %.9f -> %.6f, %.6f", easting, northing, lon, lat, e, n)
+			}
+		}
+	}
+}
+
+func TestTransformationForWKT(t *testing.T) {
+	d96 := `PROJCS["Slovenia_1996_Slovene_National_Grid",GEOGCS["GCS_Slovenia_1996",DATUM["D_Slovenia_Geodetic_Datum_1996",SPHEROID["GRS_1980",6378137.0,298.257222101]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]],PROJECTION["Transverse_Mercator"],PARAMETER["False_Easting",500000.0],PARAMETER["False_Northing",-5000000.0],PARAMETER["Central_Meridian",15.0],PARAMETER["Scale_Factor",0.9999],PARAMETER["Latitude_Of_Origin",0.0],UNIT["Meter",1.0]]`
+	wgs84 := `GEOGCS["GCS_WGS_1984",DATUM["D_WGS_1984",SPHEROID["WGS_1984",6378137.0,298.257223563]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]]`
+	d48 := `PROJCS["MGI_Slovenia_Grid",GEOGCS["GCS_MGI",DATUM["D_MGI",SPHEROID["Bessel_1841",6377397.155,299.1528128]]],PROJECTION["Transverse_Mercator"]]`
+
+	toWGS84, err := transformationForWKT(d96)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lon, lat := toWGS84(500000, 95576.3177)
+	assertEqual(t, round(lon), 15.0)
+	assertEqual(t, round(lat), 46.0)
+
+	toWGS84, err = transformationForWKT(wgs84)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lon, lat = toWGS84(14.5, 46.1)
+	assertEqual(t, lon, 14.5)
+	assertEqual(t, lat, 46.1)
+
+	if _, err := transformationForWKT(d48); err == nil {
+		t.Error("D48/GK should not be supported")
+	}
+}
+
+func BenchmarkD96TMToWGS84(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		d96tm.ToWGS84(461755.9762, 101252.0983)
+	}
+}
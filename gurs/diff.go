@@ -0,0 +1,250 @@
+package gurs
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io/fs"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	geojson "github.com/paulmach/go.geojson"
+)
+
+// ChangeType classifies how an address differs between two GURS snapshots
+type ChangeType string
+
+// An address can be both moved and retagged, it is then listed once for each
+const (
+	ChangeAdded    ChangeType = "added"
+	ChangeRemoved  ChangeType = "removed"
+	ChangeMoved    ChangeType = "moved"
+	ChangeRetagged ChangeType = "retagged"
+)
+
+// changeTypes in report order
+var changeTypes = []ChangeType{ChangeAdded, ChangeRemoved, ChangeMoved, ChangeRetagged}
+
+// TagChange is a single tag value before and after, empty if the tag was not set
+type TagChange struct {
+	Key, Before, After string
+}
+
+// Change is a difference of one address (by ref:gurs:hs_mid) between two snapshots
+type Change struct {
+	HsMid string
+	Type  ChangeType
+	// "Ime_občine/Ime_naselja" of the newer snapshot, older one for removed addresses
+	Category string
+	// meters, only for moved
+	Distance float64
+	// only for retagged, sorted by key
+	Tags          []TagChange
+	Before, After *geojson.Feature
+}
+
+// ReadSnapshot converts the HS records of one GURS release in dataDir (see OpenSource) with the profile and the overrides
+// of that release (names changed by other overrides are retagged in Diff), rejected and degraded records are logged only
+func ReadSnapshot(dataDir, overridesDir string, profile *TaggingProfile) (map[string]*geojson.FeatureCollection, error) {
+	converter, err := NewConverter(dataDir, overridesDir)
+	if err != nil {
+		return nil, err
+	}
+	defer converter.Close()
+	converter.Profile = profile
+
+	if err := converter.ReadLookups(); err != nil {
+		return nil, err
+	}
+	hs, err := converter.OpenHS("")
+	if err != nil {
+		return nil, err
+	}
+	defer hs.Close()
+
+	featureCollections, err := converter.ReadShapefile(hs)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("%s: %s", dataDir, converter.Rejections().Summary())
+	return featureCollections, nil
+}
+
+type categorizedFeature struct {
+	feature  *geojson.Feature
+	category string
+}
+
+func featuresByRef(featureCollections map[string]*geojson.FeatureCollection, ref string) map[string]categorizedFeature {
+	features := make(map[string]categorizedFeature)
+	for category, featureCollection := range featureCollections {
+		for _, f := range featureCollection.Features {
+			features[f.PropertyMustString(ref)] = categorizedFeature{feature: f, category: category}
+		}
+	}
+	return features
+}
+
+// Diff compares two snapshots (as returned by ReadShapefile) by ref:gurs:hs_mid,
+// changes are sorted by category, HS_MID and change type; the source date of the profile
+// changes with every update of a record and is not compared
+func Diff(before, after map[string]*geojson.FeatureCollection, profile *TaggingProfile) []Change {
+	beforeByRef := featuresByRef(before, profile.Keys.Ref)
+	afterByRef := featuresByRef(after, profile.Keys.Ref)
+
+	var changes []Change
+	for ref, old := range beforeByRef {
+		if _, found := afterByRef[ref]; !found {
+			changes = append(changes, Change{HsMid: ref, Type: ChangeRemoved, Category: old.category, Before: old.feature})
+		}
+	}
+
+	for ref, current := range afterByRef {
+		old, found := beforeByRef[ref]
+		if !found {
+			changes = append(changes, Change{HsMid: ref, Type: ChangeAdded, Category: current.category, After: current.feature})
+			continue
+		}
+
+		oldPoint, newPoint := old.feature.Geometry.Point, current.feature.Geometry.Point
+		if oldPoint[0] != newPoint[0] || oldPoint[1] != newPoint[1] {
+			changes = append(changes, Change{HsMid: ref, Type: ChangeMoved, Category: current.category,
+				Distance: Distance(oldPoint[0], oldPoint[1], newPoint[0], newPoint[1]),
+				Before:   old.feature, After: current.feature})
+		}
+
+		if tags := diffTags(old.feature.Properties, current.feature.Properties, profile.Keys.SourceDate); len(tags) > 0 {
+			changes = append(changes, Change{HsMid: ref, Type: ChangeRetagged, Category: current.category,
+				Tags: tags, Before: old.feature, After: current.feature})
+		}
+	}
+
+	typeOrder := make(map[ChangeType]int)
+	for i, changeType := range changeTypes {
+		typeOrder[changeType] = i
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Category != changes[j].Category {
+			return changes[i].Category < changes[j].Category
+		}
+		if changes[i].HsMid != changes[j].HsMid {
+			return lessMid(changes[i].HsMid, changes[j].HsMid)
+		}
+		return typeOrder[changes[i].Type] < typeOrder[changes[j].Type]
+	})
+
+	return changes
+}
+
+func diffTags(before, after map[string]interface{}, ignored string) []TagChange {
+	keys := make(map[string]bool)
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+
+	var tags []TagChange
+	for key := range keys {
+		if key == ignored {
+			continue
+		}
+		oldValue, _ := before[key].(string)
+		newValue, _ := after[key].(string)
+		if oldValue != newValue {
+			tags = append(tags, TagChange{Key: key, Before: oldValue, After: newValue})
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Key < tags[j].Key
+	})
+	return tags
+}
+
+// DiffSummary counts changes by type for one category
+type DiffSummary struct {
+	Category string
+	Counts   map[ChangeType]int
+}
+
+// SummarizeDiff returns change counts per category ("Ime_občine/Ime_naselja"), sorted by category
+func SummarizeDiff(changes []Change) []DiffSummary {
+	var summaries []DiffSummary
+	for _, change := range changes {
+		if len(summaries) == 0 || summaries[len(summaries)-1].Category != change.Category {
+			summaries = append(summaries, DiffSummary{Category: change.Category, Counts: make(map[ChangeType]int)})
+		}
+		summaries[len(summaries)-1].Counts[change.Type]++
+	}
+	return summaries
+}
+
+// WriteDiffGeoJSON saves changes as GeoJSON: points for added, removed and retagged addresses
+// (with before/after tag values), lines from the old to the new position for moved ones; HS_MID is the ref of the profile
+func WriteDiffGeoJSON(changes []Change, filename string, profile *TaggingProfile) error {
+	featureCollection := geojson.NewFeatureCollection()
+	for _, change := range changes {
+		var f *geojson.Feature
+		switch change.Type {
+		case ChangeAdded:
+			f = geojson.NewPointFeature(change.After.Geometry.Point)
+		case ChangeRemoved:
+			f = geojson.NewPointFeature(change.Before.Geometry.Point)
+		case ChangeMoved:
+			f = geojson.NewLineStringFeature([][]float64{change.Before.Geometry.Point, change.After.Geometry.Point})
+			f.SetProperty("distance", change.Distance)
+		case ChangeRetagged:
+			f = geojson.NewPointFeature(change.After.Geometry.Point)
+			for _, tag := range change.Tags {
+				f.SetProperty(tag.Key+":before", tag.Before)
+				f.SetProperty(tag.Key+":after", tag.After)
+			}
+		}
+		f.SetProperty("change", string(change.Type))
+		f.SetProperty("category", change.Category)
+		f.SetProperty(profile.Keys.Ref, change.HsMid)
+		featureCollection.AddFeature(f)
+	}
+
+	rawJSON, err := json.MarshalIndent(featureCollection, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, rawJSON, fs.FileMode(0644))
+}
+
+// WriteDiffSummaryCSV saves change counts per municipality and settlement
+func WriteDiffSummaryCSV(summaries []DiffSummary, filename string) error {
+	csvFile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer csvFile.Close()
+
+	writer := csv.NewWriter(csvFile)
+	header := []string{"municipality", "settlement"}
+	for _, changeType := range changeTypes {
+		header = append(header, string(changeType))
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, summary := range summaries {
+		municipality, settlement, _ := strings.Cut(summary.Category, "/")
+		row := []string{strings.ReplaceAll(municipality, "_", " "), strings.ReplaceAll(settlement, "_", " ")}
+		for _, changeType := range changeTypes {
+			row = append(row, strconv.Itoa(summary.Counts[changeType]))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return csvFile.Close()
+}
@@ -0,0 +1,113 @@
+package gurs
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	_, before, _ := convertTestData(t, testRecords)
+
+	records := []hsTestRecord{
+		// unchanged
+		testRecords[0],
+		// moved 10 m east
+		{"1002", "3a", "102", "202", "302", "11027123", "V", 398504.7924, 46387.3150},
+		// 1003 removed, 1004 retagged to another street and house number
+		{"1004", "7a", "101", "203", "301", "11026975", "V", 462100, 101600},
+		// added
+		{"1006", "1", "101", "201", "301", "11026975", "V", 461900, 101400},
+	}
+	_, after, _ := convertTestData(t, records)
+
+	changes := Diff(before, after, DefaultTaggingProfile())
+	assertEqual(t, len(changes), 4)
+
+	byType := make(map[ChangeType]Change)
+	for _, change := range changes {
+		byType[change.Type] = change
+	}
+
+	assertEqual(t, byType[ChangeAdded].HsMid, "1006")
+	assertEqual(t, byType[ChangeAdded].Category, "Ljubljana/Ljubljana")
+	assertEqual(t, byType[ChangeRemoved].HsMid, "1003")
+	assertEqual(t, byType[ChangeRemoved].Category, "Ljubljana/Zgornja_vas")
+
+	moved := byType[ChangeMoved]
+	assertEqual(t, moved.HsMid, "1002")
+	if moved.Distance < 9.9 || moved.Distance > 10.1 {
+		t.Errorf("moved distance %f should be about 10 m", moved.Distance)
+	}
+
+	retagged := byType[ChangeRetagged]
+	assertEqual(t, retagged.HsMid, "1004")
+	assertEqual(t, len(retagged.Tags), 2)
+	assertEqual(t, retagged.Tags[0], TagChange{tagHousenumber, "7", "7a"})
+	assertEqual(t, retagged.Tags[1], TagChange{tagStreet, "Cesta slovenskih kmečkih uporov", "Slovenska cesta"})
+
+	summaries := SummarizeDiff(changes)
+	assertEqual(t, len(summaries), 3)
+	assertEqual(t, summaries[0].Category, "Ljubljana/Ljubljana")
+	assertEqual(t, summaries[0].Counts[ChangeAdded], 1)
+	assertEqual(t, summaries[1].Counts[ChangeRemoved], 1)
+	assertEqual(t, summaries[1].Counts[ChangeRetagged], 1)
+	assertEqual(t, summaries[2].Counts[ChangeMoved], 1)
+
+	dir := t.TempDir()
+	if err := WriteDiffGeoJSON(changes, filepath.Join(dir, "diff.geojson"), DefaultTaggingProfile()); err != nil {
+		t.Fatal(err)
+	}
+	summaryFileName := filepath.Join(dir, "diff-summary.csv")
+	if err := WriteDiffSummaryCSV(summaries, summaryFileName); err != nil {
+		t.Fatal(err)
+	}
+	csvFile, err := os.Open(summaryFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer csvFile.Close()
+	rows, err := csv.NewReader(csvFile).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, len(rows), 4)
+	assertEqual(t, rows[2][1], "Zgornja vas")
+	assertEqual(t, rows[2][3], "1")
+}
+
+func TestReadSnapshotOverrides(t *testing.T) {
+	dir := t.TempDir()
+	writeTestData(t, dir, testRecords)
+	profile := DefaultTaggingProfile()
+
+	// converted before the override of Cesta slov. kmečkih uporov was added
+	before, err := ReadSnapshot(dir, t.TempDir(), profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := ReadSnapshot(dir, testOverridesDir, profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := Diff(before, after, profile)
+	assertEqual(t, len(changes), 1)
+	assertEqual(t, changes[0].HsMid, "1004")
+	assertEqual(t, changes[0].Tags[0], TagChange{tagStreet, "Cesta slov. kmečkih uporov", "Cesta slovenskih kmečkih uporov"})
+
+	before, err = ReadSnapshot(dir, testOverridesDir, profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, len(Diff(before, after, profile)), 0)
+}
+
+func TestDistance(t *testing.T) {
+	// one minute of latitude is about one nautical mile
+	distance := Distance(14.5, 46, 14.5, 46+1.0/60)
+	if distance < 1850 || distance > 1856 {
+		t.Errorf("%f should be about 1852 m", distance)
+	}
+	assertEqual(t, Distance(14.5, 46, 14.5, 46), 0.0)
+}
@@ -0,0 +1,262 @@
+package gurs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPortalURL is the GURS e-geodetski podatki portal
+const DefaultPortalURL = "https://egp.gu.gov.si/egp/"
+
+// DefaultMaxAge of downloaded files before they are checked for updates
+const DefaultMaxAge = 720 * time.Minute
+
+// SourceFile is a file available on the portal by numeric id
+type SourceFile struct {
+	ID   int
+	Name string
+}
+
+// SourceFiles are the RPE archives read by the converter
+var SourceFiles = []SourceFile{
+	{ID: 105, Name: "RPE_PE.ZIP"},
+	{ID: 106, Name: "RPE_UL.ZIP"},
+	{ID: 107, Name: "RPE_HS.ZIP"},
+}
+
+// suffix of partially downloaded files, resumed by the next run
+const partialSuffix = ".part"
+
+// <input type="hidden" name="_csrf" value="089070ed-b40a-4e3c-ab22-422de0daffff" />
+var csrfPattern = regexp.MustCompile(`name="_csrf"\s+value="([^"]+)"`)
+
+// NewPortalClient returns a client trusting only the CA certificates in caFile (sigov-ca2.pem),
+// with session cookies kept in memory
+func NewPortalClient(caFile string) (*http.Client, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s: no PEM certificates", caFile)
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+		},
+		Jar: jar,
+	}, nil
+}
+
+// Downloader fetches SourceFiles from the portal, like wget -N with resume
+type Downloader struct {
+	Client      *http.Client
+	BaseURL     string
+	Credentials Credentials
+	// files in the destination directory newer than this are not checked for updates
+	MaxAge time.Duration
+	Files  []SourceFile
+}
+
+// NewDownloader returns a downloader of SourceFiles from DefaultPortalURL
+func NewDownloader(client *http.Client, credentials Credentials) *Downloader {
+	return &Downloader{
+		Client:      client,
+		BaseURL:     DefaultPortalURL,
+		Credentials: credentials,
+		MaxAge:      DefaultMaxAge,
+		Files:       SourceFiles,
+	}
+}
+
+// Stale returns files missing in dir or older than MaxAge
+func (d *Downloader) Stale(dir string) []SourceFile {
+	var stale []SourceFile
+	for _, file := range d.Files {
+		info, err := os.Stat(filepath.Join(dir, file.Name))
+		if err != nil || time.Since(info.ModTime()) > d.MaxAge {
+			stale = append(stale, file)
+		}
+	}
+	return stale
+}
+
+// Download logs in and fetches all files to dir if any of them is stale,
+// files not modified on the portal are kept and interrupted transfers are resumed
+func (d *Downloader) Download(dir string) error {
+	stale := d.Stale(dir)
+	if len(stale) == 0 {
+		log.Printf("No need to download anything (source files are already there and not older than %v)", d.MaxAge)
+		return nil
+	}
+	log.Printf("Need to download %d files (they are either missing or older than %v)", len(stale), d.MaxAge)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := d.login(); err != nil {
+		return err
+	}
+	for _, file := range d.Files {
+		if err := d.downloadFile(file, dir); err != nil {
+			return fmt.Errorf("%s: %w", file.Name, err)
+		}
+	}
+	return nil
+}
+
+func (d *Downloader) url(path string) string {
+	return strings.TrimSuffix(d.BaseURL, "/") + "/" + path
+}
+
+// login posts the credentials with the CSRF token of the login form, the session is kept in the client cookie jar
+func (d *Downloader) login() error {
+	response, err := d.Client.Get(d.url("login.html"))
+	if err != nil {
+		return err
+	}
+	page, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("login.html: %s", response.Status)
+	}
+	match := csrfPattern.FindSubmatch(page)
+	if match == nil {
+		return errors.New("login.html: no CSRF token found")
+	}
+
+	response, err = d.Client.PostForm(d.url("login.html"), url.Values{
+		"username": {d.Credentials.Username},
+		"password": {d.Credentials.Password},
+		"_csrf":    {string(match[1])},
+	})
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, response.Body)
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("login: %s", response.Status)
+	}
+	// failed logins are redirected back to the login form
+	if strings.HasSuffix(response.Request.URL.Path, "/login.html") {
+		return fmt.Errorf("login as %q failed", d.Credentials.Username)
+	}
+	return nil
+}
+
+// downloadFile saves the file as <name>.part and renames it when complete, with the portal modification time
+func (d *Downloader) downloadFile(file SourceFile, dir string) error {
+	filename := filepath.Join(dir, file.Name)
+	partFilename := filename + partialSuffix
+
+	request, err := http.NewRequest(http.MethodGet, d.url(fmt.Sprintf("download-file.html?id=%d&format=10&d96=1", file.ID)), nil)
+	if err != nil {
+		return err
+	}
+	// partial file has the modification time of the portal file, resume only if it is still the same
+	var offset int64
+	if info, err := os.Stat(partFilename); err == nil && info.Size() > 0 {
+		offset = info.Size()
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		request.Header.Set("If-Range", info.ModTime().UTC().Format(http.TimeFormat))
+	} else if info, err := os.Stat(filename); err == nil {
+		request.Header.Set("If-Modified-Since", info.ModTime().UTC().Format(http.TimeFormat))
+	}
+
+	response, err := d.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	switch response.StatusCode {
+	case http.StatusNotModified:
+		log.Printf("%s not modified on the server, keeping it", filename)
+		return nil
+	case http.StatusPartialContent:
+		if start := contentRangeStart(response.Header.Get("Content-Range")); start != offset {
+			return fmt.Errorf("resuming at %d, got range starting at %d", offset, start)
+		}
+		log.Printf("Resuming %s at %d bytes", filename, offset)
+		flags = os.O_WRONLY | os.O_APPEND
+	case http.StatusOK:
+		offset = 0
+	default:
+		return errors.New(response.Status)
+	}
+	if _, params, err := mime.ParseMediaType(response.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" && params["filename"] != file.Name {
+		log.Printf("%s: portal names it %s", filename, params["filename"])
+	}
+
+	modified, err := http.ParseTime(response.Header.Get("Last-Modified"))
+	if err != nil {
+		modified = time.Now()
+	}
+	partFile, err := os.OpenFile(partFilename, flags, 0644)
+	if err != nil {
+		return err
+	}
+	// set even if interrupted, so the next run can resume
+	defer os.Chtimes(partFilename, modified, modified)
+
+	written, err := io.Copy(partFile, response.Body)
+	if err == nil {
+		err = partFile.Sync()
+	}
+	if closeErr := partFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if response.ContentLength >= 0 && written != response.ContentLength {
+		return fmt.Errorf("got %d of %d bytes", written, response.ContentLength)
+	}
+
+	if err := os.Chtimes(partFilename, modified, modified); err != nil {
+		return err
+	}
+	if err := os.Rename(partFilename, filename); err != nil {
+		return err
+	}
+	log.Printf("Saved %d bytes to %s.", offset+written, filename)
+	return nil
+}
+
+// contentRangeStart returns the first byte of "bytes 100-199/200", -1 if invalid
+func contentRangeStart(contentRange string) int64 {
+	start, _, found := strings.Cut(strings.TrimPrefix(contentRange, "bytes "), "-")
+	if !found {
+		return -1
+	}
+	offset, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return offset
+}
@@ -0,0 +1,212 @@
+package gurs
+
+import (
+	"bytes"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testPortal stands in for the portal: a login form with a CSRF token, a session cookie and downloads by id
+type testPortal struct {
+	*httptest.Server
+	files    map[string][]byte
+	names    map[string]string
+	modified time.Time
+	mutex    sync.Mutex
+	// requests of download-file.html with their Range header
+	downloads []string
+}
+
+func newTestPortal(t *testing.T) *testPortal {
+	t.Helper()
+
+	portal := &testPortal{files: make(map[string][]byte), names: make(map[string]string),
+		modified: time.Date(2024, 1, 16, 3, 0, 0, 0, time.UTC)}
+	for _, file := range SourceFiles {
+		portal.files[strconv.Itoa(file.ID)] = bytes.Repeat([]byte(file.Name), 1000)
+		portal.names[strconv.Itoa(file.ID)] = file.Name
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/egp/login.html", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			http.SetCookie(w, &http.Cookie{Name: "JSESSIONID", Value: "anonymous", Path: "/egp/"})
+			fmt.Fprint(w, `<form method="post"><input type="hidden" name="_csrf" value="089070ed-b40a-4e3c-ab22-422de0daffff" /></form>`)
+			return
+		}
+		session, err := r.Cookie("JSESSIONID")
+		if err != nil || session.Value != "anonymous" || r.FormValue("_csrf") != "089070ed-b40a-4e3c-ab22-422de0daffff" ||
+			r.FormValue("username") != "user" || r.FormValue("password") != "secret" {
+			http.Redirect(w, r, "/egp/login.html?error", http.StatusFound)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "JSESSIONID", Value: "authenticated", Path: "/egp/"})
+		http.Redirect(w, r, "/egp/index.html", http.StatusFound)
+	})
+	mux.HandleFunc("/egp/index.html", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/egp/download-file.html", func(w http.ResponseWriter, r *http.Request) {
+		session, err := r.Cookie("JSESSIONID")
+		if err != nil || session.Value != "authenticated" {
+			http.Redirect(w, r, "/egp/login.html", http.StatusFound)
+			return
+		}
+		content, found := portal.files[r.FormValue("id")]
+		if !found || r.FormValue("format") != "10" || r.FormValue("d96") != "1" {
+			http.NotFound(w, r)
+			return
+		}
+		portal.mutex.Lock()
+		portal.downloads = append(portal.downloads, r.FormValue("id")+" "+r.Header.Get("Range"))
+		portal.mutex.Unlock()
+		w.Header().Set("Content-Disposition", `attachment; filename="`+portal.names[r.FormValue("id")]+`"`)
+		http.ServeContent(w, r, "", portal.modified, bytes.NewReader(content))
+	})
+	portal.Server = httptest.NewTLSServer(mux)
+	t.Cleanup(portal.Close)
+	return portal
+}
+
+// downloader trusts only the portal certificate, like sigov-ca2.pem
+func (portal *testPortal) downloader(t *testing.T) *Downloader {
+	t.Helper()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: portal.Certificate().Raw})
+	if err := os.WriteFile(caFile, certificate, 0644); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewPortalClient(caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	downloader := NewDownloader(client, Credentials{Username: "user", Password: "secret"})
+	downloader.BaseURL = portal.URL + "/egp/"
+	return downloader
+}
+
+func (portal *testPortal) takeDownloads() string {
+	portal.mutex.Lock()
+	defer portal.mutex.Unlock()
+	downloads := strings.Join(portal.downloads, ",")
+	portal.downloads = nil
+	return downloads
+}
+
+func TestDownload(t *testing.T) {
+	portal := newTestPortal(t)
+	dir := filepath.Join(t.TempDir(), "downloaded")
+	downloader := portal.downloader(t)
+
+	if err := downloader.Download(dir); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, portal.takeDownloads(), "105 ,106 ,107 ")
+	// only the archives, no login page or cookies
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, len(entries), 3)
+	for _, file := range SourceFiles {
+		content, err := os.ReadFile(filepath.Join(dir, file.Name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, bytes.Equal(content, portal.files[strconv.Itoa(file.ID)]), true)
+	}
+
+	// files have the portal time, older than MaxAge: checked again but not modified
+	info, err := os.Stat(filepath.Join(dir, "RPE_HS.ZIP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, info.ModTime().UTC(), portal.modified)
+	assertEqual(t, len(downloader.Stale(dir)), 3)
+	if err := downloader.Download(dir); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, portal.takeDownloads(), "105 ,106 ,107 ")
+
+	// fresh files are not checked
+	now := time.Now()
+	for _, file := range SourceFiles {
+		if err := os.Chtimes(filepath.Join(dir, file.Name), now, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := downloader.Download(dir); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, portal.takeDownloads(), "")
+}
+
+func TestDownloadResume(t *testing.T) {
+	portal := newTestPortal(t)
+	dir := t.TempDir()
+	downloader := portal.downloader(t)
+	downloader.Files = SourceFiles[2:]
+
+	content := portal.files["107"]
+	partFilename := filepath.Join(dir, "RPE_HS.ZIP"+partialSuffix)
+	if err := os.WriteFile(partFilename, content[:1000], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(partFilename, portal.modified, portal.modified); err != nil {
+		t.Fatal(err)
+	}
+	if err := downloader.Download(dir); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, portal.takeDownloads(), "107 bytes=1000-")
+	downloaded, err := os.ReadFile(filepath.Join(dir, "RPE_HS.ZIP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, bytes.Equal(downloaded, content), true)
+	_, err = os.Stat(partFilename)
+	assertEqual(t, os.IsNotExist(err), true)
+
+	// file changed on the portal since the partial download: downloaded again
+	if err := os.WriteFile(partFilename, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	downloader.MaxAge = 0
+	if err := downloader.Download(dir); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, portal.takeDownloads(), "107 bytes=3-")
+	downloaded, err = os.ReadFile(filepath.Join(dir, "RPE_HS.ZIP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, bytes.Equal(downloaded, content), true)
+}
+
+func TestDownloadFailures(t *testing.T) {
+	portal := newTestPortal(t)
+
+	downloader := portal.downloader(t)
+	downloader.Credentials.Password = "wrong"
+	err := downloader.Download(t.TempDir())
+	assertEqual(t, err.Error(), `login as "user" failed`)
+
+	// the pinned CA does not trust other servers
+	client, err := NewPortalClient("../sigov-ca2.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	downloader = NewDownloader(client, Credentials{Username: "user", Password: "secret"})
+	downloader.BaseURL = portal.URL + "/egp/"
+	err = downloader.Download(t.TempDir())
+	assertEqual(t, strings.Contains(err.Error(), "certificate"), true)
+	assertEqual(t, portal.takeDownloads(), "")
+}
@@ -0,0 +1,25 @@
+package gurs
+
+import (
+	"golang.org/x/text/encoding/charmap"
+)
+
+// DecodeWindows1250bytes decodes win1250 []byte and returns UTF-8 string
+func DecodeWindows1250bytes(enc []byte) string {
+	dec := charmap.Windows1250.NewDecoder()
+	out, _ := dec.Bytes(enc)
+	return string(out)
+	// return strings.Trim(string(out), "\u0000") // trim null characters to remove null strings (when no bilingual name)
+}
+
+// DecodeWindows1250 decodes win1250 string and returns UTF-8 string
+func DecodeWindows1250(str string) string {
+	return DecodeWindows1250bytes([]byte(str))
+}
+
+// EncodeWindows1250 encodes the given utf string into Windows 1250
+func EncodeWindows1250(inp string) string {
+	enc := charmap.Windows1250.NewEncoder()
+	out, _ := enc.String(inp)
+	return out
+}
@@ -0,0 +1,36 @@
+package gurs
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+var encodingTestCases = [...]struct {
+	win1250, utf string
+}{
+	// https://en.wikipedia.org/wiki/Windows-1250
+	{"", ""},
+	{" ", " "},
+	{"a", "a"},
+	{"a\nb c123!#", "a\nb c123!#"},
+	{"\xe8", "č"},
+	{"abc \xe8\x9e\x9a\xe6\xf0---\xc8\x8e\x8a\xc6\xd0", "abc čžšćđ---ČŽŠĆĐ"},
+}
+
+func TestDecodeWindows1250(t *testing.T) {
+	for _, table := range encodingTestCases {
+		result := DecodeWindows1250(table.win1250)
+		if result != table.utf {
+			t.Errorf("Decoding %s: %s gave: %s: %s instead of expected %s: %s", table.win1250, hex.Dump([]byte(table.win1250)), result, hex.Dump([]byte(result)), table.utf, hex.Dump([]byte(table.utf)))
+		}
+	}
+}
+
+func TestEncodeWindows1250(t *testing.T) {
+	for _, table := range encodingTestCases {
+		result := EncodeWindows1250(table.utf)
+		if result != table.win1250 {
+			t.Errorf("Encoding %s (%v) gave: %s (%v), instead of expected: %s (%v).", table.utf, hex.Dump([]byte(table.utf)), result, hex.Dump([]byte(result)), table.win1250, hex.Dump([]byte(table.win1250)))
+		}
+	}
+}
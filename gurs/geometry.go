@@ -0,0 +1,17 @@
+package gurs
+
+import "math"
+
+// mean Earth radius in meters
+const earthRadius = 6371008.8
+
+// Distance returns the great-circle distance in meters between two WGS84 points given as lon, lat
+func Distance(lon1, lat1, lon2, lat2 float64) float64 {
+	phi1, phi2 := degreesToRadians(lat1), degreesToRadians(lat2)
+	deltaPhi := phi2 - phi1
+	deltaLambda := degreesToRadians(lon2 - lon1)
+
+	a := math.Sin(deltaPhi/2)*math.Sin(deltaPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(deltaLambda/2)*math.Sin(deltaLambda/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,36 @@
+package gurs
+
+import "math"
+
+// hilbertIndex returns the distance of the cell along a Hilbert curve filling the 2^32 x 2^32 grid,
+// cells next to each other on the curve are always neighbours in the grid
+func hilbertIndex(x, y uint32) uint64 {
+	var d uint64
+	for s := uint32(1) << 31; s > 0; s >>= 1 {
+		var rx, ry uint32
+		if x&s != 0 {
+			rx = 1
+		}
+		if y&s != 0 {
+			ry = 1
+		}
+		d += uint64(s) * uint64(s) * uint64((3*rx)^ry)
+		// rotate the quadrant so the curve continues from the previous one
+		if ry == 0 {
+			if rx == 1 {
+				x, y = ^x, ^y
+			}
+			x, y = y, x
+		}
+	}
+	return d
+}
+
+// spatialIndex returns the position of WGS84 coordinates on the Hilbert curve over the whole world,
+// with the coordinates rounded to the 7 decimals of the converter (about 1 cm) the result does not depend
+// on floating point noise
+func spatialIndex(lon, lat float64) uint64 {
+	x := math.Round((math.Max(-180, math.Min(180, lon)) + 180) * roundingFactor)
+	y := math.Round((math.Max(-90, math.Min(90, lat)) + 90) * roundingFactor)
+	return hilbertIndex(uint32(x), uint32(y))
+}
@@ -0,0 +1,47 @@
+package gurs
+
+import (
+	"testing"
+)
+
+func TestHilbertIndex(t *testing.T) {
+	// the curve fills the 16 x 16 cells at the origin first, each cell once, moving to a neighbour every step
+	const size = 16
+	cells := make(map[uint64][2]int)
+	for x := 0; x < size; x++ {
+		for y := 0; y < size; y++ {
+			d := hilbertIndex(uint32(x), uint32(y))
+			if d >= size*size {
+				t.Fatalf("hilbertIndex(%d, %d) = %d is outside of the first %d cells", x, y, d, size*size)
+			}
+			cells[d] = [2]int{x, y}
+		}
+	}
+	assertEqual(t, len(cells), size*size)
+	for d := uint64(1); d < size*size; d++ {
+		previous, cell := cells[d-1], cells[d]
+		if abs(cell[0]-previous[0])+abs(cell[1]-previous[1]) != 1 {
+			t.Errorf("cells %d %v and %d %v are not neighbours", d-1, previous, d, cell)
+		}
+	}
+
+	assertEqual(t, hilbertIndex(0, 0), uint64(0))
+	assertEqual(t, hilbertIndex(1<<32-1, 0), uint64(1<<64-1))
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+func TestSpatialIndex(t *testing.T) {
+	// rounded to 7 decimals
+	assertEqual(t, spatialIndex(14.5058, 46.0569), spatialIndex(14.50580000001, 46.05689999999))
+	if spatialIndex(14.5058, 46.0569) == spatialIndex(14.5058001, 46.0569) {
+		t.Error("1 cm apart should not be the same cell")
+	}
+	assertEqual(t, spatialIndex(-180, -90), uint64(0))
+	assertEqual(t, spatialIndex(-200, -100), uint64(0))
+}
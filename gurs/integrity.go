@@ -0,0 +1,161 @@
+package gurs
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maximum number of HS_MIDs listed per orphaned key in the report
+const maxListedHsMids = 10
+
+// foreignKey is a HS column referencing one of the lookup tables
+type foreignKey struct {
+	table  string
+	column int
+	// lookup rows by MID with their names
+	lookup map[string]string
+	// reason for records with the key missing in lookup
+	reason Reason
+	// empty or 0 value means no reference (eg. houses without a street)
+	optional bool
+}
+
+// TableIntegrity lists references between HS records and one lookup table
+type TableIntegrity struct {
+	Table  string
+	lookup map[string]string
+	// number of HS records by referenced MID
+	references map[string]int
+	// HS_MIDs of records by referenced MID missing in lookup
+	orphans map[string][]string
+	// number of HS records referencing missing MIDs
+	orphanRecords int
+}
+
+// Orphans returns MIDs referenced by HS records but missing in the lookup table, sorted
+func (t *TableIntegrity) Orphans() []string {
+	orphans := make([]string, 0, len(t.orphans))
+	for mid := range t.orphans {
+		orphans = append(orphans, mid)
+	}
+	sortMids(orphans)
+	return orphans
+}
+
+// Unreferenced returns MIDs of lookup rows not referenced by any HS record, sorted
+func (t *TableIntegrity) Unreferenced() []string {
+	var unreferenced []string
+	for mid := range t.lookup {
+		if _, referenced := t.references[mid]; !referenced {
+			unreferenced = append(unreferenced, mid)
+		}
+	}
+	sortMids(unreferenced)
+	return unreferenced
+}
+
+// lessMid compares numeric MIDs numerically
+func lessMid(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+// sortMids sorts numeric MIDs numerically
+func sortMids(mids []string) {
+	sort.Slice(mids, func(i, j int) bool {
+		return lessMid(mids[i], mids[j])
+	})
+}
+
+// Integrity cross-checks foreign keys (PT_MID, UL_MID, NA_MID, OB_MID) of HS records
+// against the lookup shapefiles, in both directions
+type Integrity struct {
+	Tables []*TableIntegrity
+}
+
+func newIntegrity(foreignKeys []foreignKey) *Integrity {
+	integrity := &Integrity{}
+	for _, key := range foreignKeys {
+		integrity.Tables = append(integrity.Tables, &TableIntegrity{
+			Table:      key.table,
+			lookup:     key.lookup,
+			references: make(map[string]int),
+			orphans:    make(map[string][]string),
+		})
+	}
+	return integrity
+}
+
+// check records references of one HS record and returns reasons for the missing ones
+func (integrity *Integrity) check(hsMid string, foreignKeys []foreignKey, attribute func(int) string) []Reason {
+	var reasons []Reason
+	for i, key := range foreignKeys {
+		mid := attribute(key.column)
+		if key.optional && (mid == "" || mid == "0") {
+			continue
+		}
+
+		table := integrity.Tables[i]
+		table.references[mid]++
+		if _, found := key.lookup[mid]; !found {
+			table.orphans[mid] = append(table.orphans[mid], hsMid)
+			table.orphanRecords++
+			reasons = append(reasons, key.reason)
+		}
+	}
+	return reasons
+}
+
+// Summary returns orphan and unreferenced counts per table, one table per line
+func (integrity *Integrity) Summary() string {
+	lines := []string{"Referential integrity of HS records:"}
+	for _, table := range integrity.Tables {
+		lines = append(lines, fmt.Sprintf("  %s: %d missing MIDs referenced by %d HS records, %d of %d rows not referenced",
+			table.Table, len(table.Orphans()), table.orphanRecords, len(table.Unreferenced()), len(table.lookup)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WriteCSV saves all orphans and unreferenced lookup rows to filename
+func (integrity *Integrity) WriteCSV(filename string) error {
+	csvFile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer csvFile.Close()
+
+	writer := csv.NewWriter(csvFile)
+	if err := writer.Write([]string{"table", "problem", "mid", "name", "hs_count", "hs_mids"}); err != nil {
+		return err
+	}
+	for _, table := range integrity.Tables {
+		for _, mid := range table.Orphans() {
+			hsMids := table.orphans[mid]
+			listed := hsMids
+			if len(listed) > maxListedHsMids {
+				listed = listed[:maxListedHsMids]
+			}
+			row := []string{table.Table, "orphan", mid, "", strconv.Itoa(len(hsMids)), strings.Join(listed, " ")}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+		for _, mid := range table.Unreferenced() {
+			row := []string{table.Table, "unreferenced", mid, table.lookup[mid], "0", ""}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return csvFile.Close()
+}
@@ -0,0 +1,63 @@
+package gurs
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIntegrity(t *testing.T) {
+	records := []hsTestRecord{
+		testRecords[0],
+		{"2001", "1", "101", "201", "301", "99999999", "V", 461800, 101300},
+		{"2002", "2", "101", "201", "301", "99999999", "V", 461800, 101300},
+		{"2003", "3", "998", "201", "301", "11026975", "V", 461800, 101300},
+		// references of invalid records are not checked
+		{"2004", "4", "999", "299", "399", "11026975", "N", 461800, 101300},
+	}
+
+	converter, _, _ := convertTestData(t, records)
+	integrity := converter.Integrity()
+
+	tables := make(map[string]*TableIntegrity)
+	for _, table := range integrity.Tables {
+		tables[table.Table] = table
+	}
+
+	assertEqual(t, strings.Join(tables["PT"].Orphans(), ","), "99999999")
+	assertEqual(t, strings.Join(tables["PT"].Unreferenced(), ","), "11027123")
+	assertEqual(t, strings.Join(tables["UL"].Orphans(), ","), "998")
+	assertEqual(t, strings.Join(tables["UL"].Unreferenced(), ","), "102,103")
+	assertEqual(t, strings.Join(tables["NA"].Orphans(), ","), "")
+	assertEqual(t, strings.Join(tables["NA"].Unreferenced(), ","), "202,203")
+	assertEqual(t, strings.Join(tables["OB"].Unreferenced(), ","), "302")
+
+	if !strings.Contains(integrity.Summary(), "PT: 1 missing MIDs referenced by 2 HS records, 1 of 2 rows not referenced") {
+		t.Errorf("unexpected summary:\n%s", integrity.Summary())
+	}
+
+	filename := filepath.Join(t.TempDir(), "integrity.csv")
+	if err := integrity.WriteCSV(filename); err != nil {
+		t.Fatal(err)
+	}
+	csvFile, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer csvFile.Close()
+	rows, err := csv.NewReader(csvFile).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, strings.Join(rows[1], ","), "UL,orphan,998,,1,2003")
+	assertEqual(t, strings.Join(rows[2], ","), "UL,unreferenced,102,Tartinijev trg,0,")
+	assertEqual(t, len(rows), 9)
+}
+
+func TestSortMids(t *testing.T) {
+	mids := []string{"100", "20", "3", "11026975"}
+	sortMids(mids)
+	assertEqual(t, strings.Join(mids, ","), "3,20,100,11026975")
+}
@@ -0,0 +1,262 @@
+package gurs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	geojson "github.com/paulmach/go.geojson"
+)
+
+// FileDigest is the size and SHA-256 checksum of a file
+type FileDigest struct {
+	// on disk, or inside an archive, eg. RPE_PE.ZIP/PT.zip/PT/PT.shp
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// OutputDigest is a saved category file with its content summary
+type OutputDigest struct {
+	FileDigest
+	Features int `json:"features"`
+	// minLon, minLat, maxLon, maxLat, empty without features
+	BBox []float64 `json:"bbox,omitempty"`
+}
+
+// ToolInfo identifies the build of the converter
+type ToolInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// VCS revision, with "-dirty" if there were uncommitted changes
+	Commit string `json:"commit,omitempty"`
+	Go     string `json:"go"`
+}
+
+// ManifestOptions are the settings of a run changing the outputs
+type ManifestOptions struct {
+	PlaceMode              PlaceMode            `json:"place_mode"`
+	MunicipalityPlaceModes map[string]PlaceMode `json:"municipality_place_modes,omitempty"`
+	Sort                   SortOrder            `json:"sort"`
+}
+
+// Manifest records where the outputs of a run came from
+type Manifest struct {
+	Created time.Time `json:"created"`
+	Tool    ToolInfo  `json:"tool"`
+	// date of the HS shapefile, YYYY-MM-DD
+	ReleaseDate string       `json:"gurs_release_date"`
+	Sources     []FileDigest `json:"sources"`
+	// override files, bilingual areas table and tagging profile
+	Overrides []FileDigest    `json:"overrides"`
+	Options   ManifestOptions `json:"options"`
+	Outputs   []OutputDigest  `json:"outputs"`
+}
+
+// NewManifest returns a manifest of the current build, version is used if the build has no module version
+func NewManifest(version string) *Manifest {
+	return &Manifest{Created: time.Now().UTC().Truncate(time.Second), Tool: CurrentTool(version)}
+}
+
+// CurrentTool reads the module version and VCS revision stamped in the binary by go build
+func CurrentTool(version string) ToolInfo {
+	tool := ToolInfo{Name: DefaultOsmGenerator, Version: version, Go: runtime.Version()}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return tool
+	}
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		tool.Version = info.Main.Version
+	}
+	var modified bool
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			tool.Commit = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if modified && tool.Commit != "" {
+		tool.Commit += "-dirty"
+	}
+	return tool
+}
+
+// AddOutput records the checksum of the saved file with the count and bounding box of its features
+func (m *Manifest) AddOutput(filename string, featureCollection *geojson.FeatureCollection) error {
+	digest, err := DigestFile(filename)
+	if err != nil {
+		return err
+	}
+	output := OutputDigest{FileDigest: digest, Features: len(featureCollection.Features)}
+	if len(featureCollection.Features) > 0 {
+		bbox := []float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+		for _, f := range featureCollection.Features {
+			point := f.Geometry.Point
+			bbox[0], bbox[1] = math.Min(bbox[0], point[0]), math.Min(bbox[1], point[1])
+			bbox[2], bbox[3] = math.Max(bbox[2], point[0]), math.Max(bbox[3], point[1])
+		}
+		output.BBox = bbox
+	}
+	m.Outputs = append(m.Outputs, output)
+	return nil
+}
+
+// Write saves the manifest as indented JSON
+func (m *Manifest) Write(filename string) error {
+	rawJSON, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(rawJSON, '\n'), fs.FileMode(0644))
+}
+
+func digest(path string, reader io.Reader) (FileDigest, error) {
+	hash := sha256.New()
+	size, err := io.Copy(hash, reader)
+	if err != nil {
+		return FileDigest{}, err
+	}
+	return FileDigest{Path: path, Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// DigestFile returns the checksum of a file on disk
+func DigestFile(filename string) (FileDigest, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return FileDigest{}, err
+	}
+	defer file.Close()
+	return digest(filepath.ToSlash(filename), file)
+}
+
+// DigestShapefile returns checksums of the .shp, .dbf and .prj (if any) files of a shapefile on disk
+func DigestShapefile(filename string) ([]FileDigest, error) {
+	base := strings.TrimSuffix(filename, filepath.Ext(filename))
+	var digests []FileDigest
+	for _, ext := range []string{".shp", ".dbf", ".prj"} {
+		d, err := DigestFile(base + ext)
+		if ext == ".prj" && errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		digests = append(digests, d)
+	}
+	return digests, nil
+}
+
+// Digests returns checksums of the archives and all shapefile members in them,
+// or of the extracted shapefiles
+func Digests(source ShapeSource) ([]FileDigest, error) {
+	switch s := source.(type) {
+	case *archiveSource:
+		return s.digests()
+	case *directorySource:
+		return s.digests()
+	}
+	return nil, nil
+}
+
+func (s *archiveSource) digests() ([]FileDigest, error) {
+	var digests []FileDigest
+	for _, filename := range s.filenames {
+		d, err := DigestFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		digests = append(digests, d)
+	}
+
+	var members []archiveMember
+	for _, byExt := range s.members {
+		for _, member := range byExt {
+			members = append(members, member)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].path < members[j].path
+	})
+	for _, member := range members {
+		d, err := digestMember(member)
+		if err != nil {
+			return nil, err
+		}
+		digests = append(digests, d)
+	}
+	return digests, nil
+}
+
+func digestMember(member archiveMember) (FileDigest, error) {
+	rc, err := member.file.Open()
+	if err != nil {
+		return FileDigest{}, err
+	}
+	defer rc.Close()
+	return digest(member.path, rc)
+}
+
+func (s *directorySource) digests() ([]FileDigest, error) {
+	shapefiles, err := filepath.Glob(filepath.Join(s.dir, "*", "*.shp"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(shapefiles)
+	var digests []FileDigest
+	for _, shapefile := range shapefiles {
+		d, err := DigestShapefile(shapefile)
+		if err != nil {
+			return nil, err
+		}
+		digests = append(digests, d...)
+	}
+	return digests, nil
+}
+
+// SourceDigests returns checksums of the GURS data read by the converter, see Digests
+func (c *Converter) SourceDigests() ([]FileDigest, error) {
+	return Digests(c.source)
+}
+
+// OverrideDigests returns checksums of the existing override files and bilingual areas table, sorted by name
+func (c *Converter) OverrideDigests() ([]FileDigest, error) {
+	columns := make(map[string]bool)
+	for _, source := range c.lookupSources() {
+		columns[source.valueCol] = true
+	}
+	var filenames []string
+	for column := range columns {
+		filenames = append(filenames, filepath.Join(c.overridesDir, column+".csv"))
+	}
+	filenames = append(filenames, filepath.Join(c.overridesDir, BilingualAreasFile))
+	sort.Strings(filenames)
+
+	var digests []FileDigest
+	for _, filename := range filenames {
+		d, err := DigestFile(filename)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		digests = append(digests, d)
+	}
+	return digests, nil
+}
@@ -0,0 +1,120 @@
+package gurs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeTestData(t, dir, testRecords)
+	converter := newTestConverter(t, dir)
+	featureCollections, err := converter.ReadShapefile(openTestHS(t, converter))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := NewManifest("1.2.3")
+	assertEqual(t, manifest.Tool.Name, DefaultOsmGenerator)
+	assertEqual(t, strings.HasPrefix(manifest.Tool.Go, "go"), true)
+
+	manifest.Sources, err = converter.SourceDigests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var layers []string
+	for _, source := range manifest.Sources {
+		if filepath.Ext(source.Path) == ".shp" {
+			layers = append(layers, filepath.Base(source.Path))
+		}
+	}
+	assertEqual(t, strings.Join(layers, ","), "HS.shp,NA.shp,OB.shp,PT.shp,UL.shp")
+
+	content, err := os.ReadFile(filepath.Join(dir, "HS", "HS.dbf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256.Sum256(content)
+	assertEqual(t, manifest.Sources[1].Path, filepath.ToSlash(filepath.Join(dir, "HS", "HS.dbf")))
+	assertEqual(t, manifest.Sources[1].SHA256, hex.EncodeToString(hash[:]))
+	assertEqual(t, manifest.Sources[1].Size, int64(len(content)))
+
+	manifest.Overrides, err = converter.OverrideDigests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, len(manifest.Overrides), 6)
+	assertEqual(t, manifest.Overrides[0].Path, "../overrides/NA_UIME.csv")
+	assertEqual(t, manifest.Overrides[5].Path, "../overrides/bilingualAreas.csv")
+
+	pattern := filepath.Join(t.TempDir(), "%s.geojson")
+	for _, category := range []string{"Ljubljana/Zgornja_vas", "Piran/Piran"} {
+		if err := WriteCategoryGeoJSON(category, featureCollections[category], pattern, DefaultTaggingProfile(), DefaultSortOrder); err != nil {
+			t.Fatal(err)
+		}
+		if err := manifest.AddOutput(filepath.Join(filepath.Dir(pattern), category+".geojson"), featureCollections[category]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	zgornja := manifest.Outputs[0]
+	assertEqual(t, zgornja.Features, 2)
+	assertEqual(t, len(zgornja.SHA256), 64)
+	points := []float64{featureCollections["Ljubljana/Zgornja_vas"].Features[0].Geometry.Point[0], featureCollections["Ljubljana/Zgornja_vas"].Features[1].Geometry.Point[0]}
+	assertEqual(t, zgornja.BBox[0], min(points[0], points[1]))
+	assertEqual(t, zgornja.BBox[2], max(points[0], points[1]))
+	piran := manifest.Outputs[1]
+	assertEqual(t, piran.BBox[0], piran.BBox[2])
+
+	manifest.Options = ManifestOptions{PlaceMode: PlaceModeStreet, MunicipalityPlaceModes: map[string]PlaceMode{"Piran": PlaceModePlace}, Sort: SortSpatial}
+
+	filename := filepath.Join(t.TempDir(), "manifest.json")
+	if err := manifest.Write(filename); err != nil {
+		t.Fatal(err)
+	}
+	rawJSON, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var read Manifest
+	if err := json.Unmarshal(rawJSON, &read); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, read.Created.Equal(manifest.Created), true)
+	assertEqual(t, read.Outputs[1].SHA256, piran.SHA256)
+	assertEqual(t, strings.Contains(string(rawJSON), `"gurs_release_date"`), true)
+	assertEqual(t, read.Options.PlaceMode, PlaceModeStreet)
+	assertEqual(t, read.Options.MunicipalityPlaceModes["Piran"], PlaceModePlace)
+	assertEqual(t, read.Options.Sort, SortSpatial)
+	assertEqual(t, strings.Contains(string(rawJSON), `"sort": "spatial"`), true)
+}
+
+func TestArchiveDigests(t *testing.T) {
+	modified := time.Date(2024, 3, 17, 10, 0, 0, 0, time.UTC)
+	inner := zipFiles(t, modified, map[string][]byte{"PT/PT.shp": []byte("shp"), "PT/PT.dbf": []byte("dbf"), "PT/readme.txt": []byte("-")})
+	downloaded := t.TempDir()
+	if err := os.WriteFile(filepath.Join(downloaded, "RPE_PE.ZIP"), zipFiles(t, modified, map[string][]byte{"PT.zip": inner}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	source, err := OpenSource(downloaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	digests, err := Digests(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, len(digests), 3)
+	assertEqual(t, filepath.Base(digests[0].Path), "RPE_PE.ZIP")
+	assertEqual(t, digests[1].Path, "RPE_PE.ZIP/PT.zip/PT/PT.dbf")
+	hash := sha256.Sum256([]byte("dbf"))
+	assertEqual(t, digests[1].SHA256, hex.EncodeToString(hash[:]))
+	assertEqual(t, digests[2].Path, "RPE_PE.ZIP/PT.zip/PT/PT.shp")
+}
@@ -0,0 +1,107 @@
+package gurs
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	geojson "github.com/paulmach/go.geojson"
+)
+
+// default generator attribute of written .osm files
+const DefaultOsmGenerator = "GursAddressesForOSM"
+
+// OsmOptions are attributes of the <osm> element of written .osm files
+type OsmOptions struct {
+	// "never" makes JOSM refuse to upload the data, "false" only warns, empty omits the attribute
+	Upload    string
+	Generator string
+	// keys the features are sorted by, DefaultTaggingProfile if nil
+	Profile *TaggingProfile
+	// DefaultSortOrder if empty
+	Order SortOrder
+}
+
+type osmXML struct {
+	XMLName   xml.Name  `xml:"osm"`
+	Version   string    `xml:"version,attr"`
+	Generator string    `xml:"generator,attr,omitempty"`
+	Upload    string    `xml:"upload,attr,omitempty"`
+	Nodes     []osmNode `xml:"node"`
+}
+
+type osmNode struct {
+	ID   int64    `xml:"id,attr"`
+	Lat  string   `xml:"lat,attr"`
+	Lon  string   `xml:"lon,attr"`
+	Tags []osmTag `xml:"tag"`
+}
+
+type osmTag struct {
+	Key   string `xml:"k,attr"`
+	Value string `xml:"v,attr"`
+}
+
+// newOsmNodes converts point features to new OSM nodes with negative ids (-1, -2...) and tags sorted by key
+func newOsmNodes(featureCollection *geojson.FeatureCollection) []osmNode {
+	nodes := make([]osmNode, 0, len(featureCollection.Features))
+	for i, f := range featureCollection.Features {
+		node := osmNode{
+			ID:  -int64(i + 1),
+			Lat: strconv.FormatFloat(f.Geometry.Point[1], 'f', -1, 64),
+			Lon: strconv.FormatFloat(f.Geometry.Point[0], 'f', -1, 64),
+		}
+		for key, value := range f.Properties {
+			node.Tags = append(node.Tags, osmTag{Key: key, Value: fmt.Sprint(value)})
+		}
+		sort.Slice(node.Tags, func(i, j int) bool {
+			return node.Tags[i].Key < node.Tags[j].Key
+		})
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// WriteCategoryOSM sorts and saves one category as OSM XML (.osm) with new nodes, eg. for opening in JOSM,
+// fileNamePattern should contain %s which is replaced by the category
+func WriteCategoryOSM(category string, featureCollection *geojson.FeatureCollection, fileNamePattern string, options OsmOptions) error {
+	switch options.Upload {
+	case "", "never", "false":
+	default:
+		// raw GURS data must never be uploaded as it is, "true" is refused too
+		return fmt.Errorf("invalid upload attribute %q, should be never, false or empty", options.Upload)
+	}
+
+	profile := options.Profile
+	if profile == nil {
+		profile = DefaultTaggingProfile()
+	}
+	SortFeatureCollection(*featureCollection, profile, options.Order)
+
+	osm := osmXML{
+		Version:   "0.6",
+		Generator: options.Generator,
+		Upload:    options.Upload,
+		Nodes:     newOsmNodes(featureCollection),
+	}
+	rawXML, err := xml.MarshalIndent(osm, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	catOsmFileName := fmt.Sprintf(fileNamePattern, category)
+	if err := os.MkdirAll(filepath.Dir(catOsmFileName), 0755); err != nil {
+		return err
+	}
+	content := append([]byte(xml.Header), rawXML...)
+	if err := os.WriteFile(catOsmFileName, append(content, '\n'), 0644); err != nil {
+		return err
+	}
+
+	log.Printf("Saved %d addresses to %s.", len(featureCollection.Features), catOsmFileName)
+	return nil
+}
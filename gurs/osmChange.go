@@ -0,0 +1,153 @@
+package gurs
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+
+	geojson "github.com/paulmach/go.geojson"
+	"github.com/paulmach/osm"
+)
+
+// OsmChange returns creates for unmatched GURS addresses, modifies of matched OSM objects with changed master tags
+// (keeping their version) and of buildings with attached addresses, with DeleteUnmatched deletes or retags of unmatched OSM objects
+func (conflation *Conflation) OsmChange() *osm.Change {
+	change := &osm.Change{Version: "0.6", Generator: conflation.options.Generator}
+
+	for i, f := range conflation.UnmatchedDataset {
+		// osm.Change.AppendCreate cannot handle negative ids
+		if change.Create == nil {
+			change.Create = &osm.OSM{}
+		}
+		change.Create.Nodes = append(change.Create.Nodes, newOsmNode(osm.NodeID(-(i+1)), f))
+	}
+
+	for _, match := range conflation.Matched {
+		if match.Changed {
+			change.AppendModify(withTags(match.Object.Object, match.Tags))
+		}
+	}
+	for _, match := range conflation.Attached {
+		change.AppendModify(withTags(match.Object.Object, match.Tags))
+	}
+
+	for _, o := range conflation.UnmatchedOSM {
+		switch conflation.unmatchedAction(o) {
+		case actionDelete:
+			change.AppendDelete(withTags(o.Object, o.Tags))
+		case actionRetag:
+			change.AppendModify(withTags(o.Object, conflation.options.Profile.withoutAddressTags(o.Tags)))
+		}
+	}
+	return change
+}
+
+// newOsmNode returns a node with all tags of the feature, sorted by key
+func newOsmNode(id osm.NodeID, f *geojson.Feature) *osm.Node {
+	node := &osm.Node{ID: id, Lat: f.Geometry.Point[1], Lon: f.Geometry.Point[0], Visible: true}
+	for key, value := range f.Properties {
+		node.Tags = append(node.Tags, osm.Tag{Key: key, Value: fmt.Sprint(value)})
+	}
+	sort.Slice(node.Tags, func(i, j int) bool {
+		return node.Tags[i].Key < node.Tags[j].Key
+	})
+	return node
+}
+
+// withTags returns a copy of the object with the given tags,
+// without the node coordinates filled in by ReadOsmExtract
+func withTags(o osm.Object, tags osm.Tags) osm.Object {
+	switch object := o.(type) {
+	case *osm.Node:
+		copied := *object
+		copied.Tags = tags
+		return &copied
+	case *osm.Way:
+		copied := *object
+		copied.Tags = tags
+		copied.Nodes = make(osm.WayNodes, len(object.Nodes))
+		for i, wayNode := range object.Nodes {
+			copied.Nodes[i] = osm.WayNode{ID: wayNode.ID}
+		}
+		return &copied
+	case *osm.Relation:
+		copied := *object
+		copied.Tags = tags
+		copied.Members = make(osm.Members, len(object.Members))
+		for i, member := range object.Members {
+			copied.Members[i] = osm.Member{Type: member.Type, Ref: member.Ref, Role: member.Role}
+		}
+		return &copied
+	}
+	return o
+}
+
+// alternativeKeys hold the settlement name of addresses without a street depending on the PlaceMode
+func (k TagKeys) alternativeKeys() map[string]string {
+	if k.Place == "" {
+		return nil
+	}
+	return map[string]string{k.Street: k.Place, k.Place: k.Street}
+}
+
+// applyMasterTags returns a copy of tags with master tags set to the feature values and whether any changed,
+// master tags missing in the feature and all other tags are kept as they are, except for a master alternative tag
+// (with its language variants) with the same value as the one set, eg. addr:street=<village> replaced by addr:place
+func applyMasterTags(tags osm.Tags, f *geojson.Feature, masterTags []string, keys TagKeys) (osm.Tags, bool) {
+	modified := append(osm.Tags(nil), tags...)
+	alternativeKeys := keys.alternativeKeys()
+
+	changed := false
+	for _, key := range masterTags {
+		value := f.PropertyMustString(key, "")
+		if value == "" {
+			continue
+		}
+		if i := tagIndex(modified, key); i >= 0 {
+			if modified[i].Value != value {
+				modified[i].Value = value
+				changed = true
+			}
+		} else {
+			modified = append(modified, osm.Tag{Key: key, Value: value})
+			changed = true
+		}
+
+		alternative, found := alternativeKeys[key]
+		if !found || !slices.Contains(masterTags, alternative) || f.PropertyMustString(alternative, "") != "" {
+			continue
+		}
+		if i := tagIndex(modified, alternative); i < 0 || modified[i].Value != value {
+			continue
+		}
+		for _, postfix := range []string{"", tagLangPostfixSlovenian, tagLangPostfixItalian, tagLangPostfixHungarian} {
+			if i := tagIndex(modified, alternative+postfix); i >= 0 && modified[i].Value == f.PropertyMustString(key+postfix, "") {
+				modified = append(modified[:i], modified[i+1:]...)
+				changed = true
+			}
+		}
+	}
+	return modified, changed
+}
+
+// tagIndex returns the position of key in tags or -1, osm.Tags.FindTag returns a copy
+func tagIndex(tags osm.Tags, key string) int {
+	for i, tag := range tags {
+		if tag.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// WriteOsmChange saves the change as an .osc file
+func WriteOsmChange(change *osm.Change, filename string) error {
+	rawXML, err := xml.MarshalIndent(change, "", "  ")
+	if err != nil {
+		return err
+	}
+	content := append([]byte(xml.Header), rawXML...)
+	return os.WriteFile(filename, append(content, '\n'), 0644)
+}
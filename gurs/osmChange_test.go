@@ -0,0 +1,99 @@
+package gurs
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	geojson "github.com/paulmach/go.geojson"
+	"github.com/paulmach/osm"
+)
+
+// TestOsmChange covers what the osmchange command used to do through the conflation: matched objects keep
+// their version and other tags, only master tags change, unmatched addresses are created with all tags
+func TestOsmChange(t *testing.T) {
+	_, featureCollections, features := convertTestData(t, testRecords)
+
+	ljubljana, zgornja, cesta := features["1001"].Geometry.Point, features["1003"].Geometry.Point, features["1004"].Geometry.Point
+	extract, err := ReadOsmExtract(writeTestExtract(t, osm.OSM{Nodes: osm.Nodes{
+		// same master tags as 1001, a different source is not a master tag
+		{ID: 10, Version: 2, Visible: true, Lon: ljubljana[0], Lat: ljubljana[1], Tags: osm.Tags{
+			{Key: tagHousenumber, Value: "12"}, {Key: tagStreet, Value: "Slovenska cesta"},
+			{Key: tagPostCode, Value: "1000"}, {Key: tagCity, Value: "Ljubljana"}, {Key: tagSource, Value: "survey"}}},
+		// 1004 with a wrong post code and a name to keep
+		{ID: 11, Version: 3, Visible: true, Lon: cesta[0] + 0.0001, Lat: cesta[1], Tags: osm.Tags{
+			{Key: "name", Value: "Gostilna"}, {Key: tagHousenumber, Value: "7"},
+			{Key: tagStreet, Value: "Cesta slovenskih kmečkih uporov"}, {Key: tagPostCode, Value: "1001"}}},
+		// too far from 1003
+		{ID: 12, Version: 1, Visible: true, Lon: zgornja[0] + 0.01, Lat: zgornja[1], Tags: osm.Tags{
+			{Key: tagHousenumber, Value: "5"}}},
+	}}), DefaultTaggingProfile().IsAddressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conflator := NewConflator(extract, DefaultConflationOptions())
+
+	change := conflator.Conflate(featureCollections["Ljubljana/Ljubljana"]).OsmChange()
+	assertEqual(t, change.Create, (*osm.OSM)(nil))
+	assertEqual(t, change.Modify, (*osm.OSM)(nil))
+
+	change = conflator.Conflate(featureCollections["Ljubljana/Zgornja_vas"]).OsmChange()
+	assertEqual(t, len(change.Create.Nodes), 1)
+	assertEqual(t, len(change.Modify.Nodes), 1)
+	assertEqual(t, change.Delete, (*osm.OSM)(nil))
+
+	created := change.Create.Nodes[0]
+	assertEqual(t, created.ID, osm.NodeID(-1))
+	assertEqual(t, created.Version, 0)
+	assertEqual(t, created.Tags.Find(tagRef), "1003")
+	assertEqual(t, created.Tags.Find(tagSource), "GURS")
+	assertEqual(t, len(created.Tags), len(features["1003"].Properties))
+
+	modified := change.Modify.Nodes[0]
+	assertEqual(t, modified.ID, osm.NodeID(11))
+	assertEqual(t, modified.Version, 3)
+	assertEqual(t, modified.Lon, cesta[0]+0.0001)
+	assertEqual(t, modified.Tags.Find("name"), "Gostilna")
+	assertEqual(t, modified.Tags.Find(tagPostCode), "1000")
+	assertEqual(t, modified.Tags.Find(tagVillage), "Zgornja vas")
+	assertEqual(t, modified.Tags.HasTag(tagRef), false)
+	assertEqual(t, modified.Tags.HasTag(tagSource), false)
+
+	oscFileName := filepath.Join(t.TempDir(), "test.osc")
+	if err := WriteOsmChange(change, oscFileName); err != nil {
+		t.Fatal(err)
+	}
+	rawXML, err := os.ReadFile(oscFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written osm.Change
+	if err := xml.Unmarshal(rawXML, &written); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, written.Generator, DefaultOsmGenerator)
+	assertEqual(t, len(written.Create.Nodes), 1)
+	assertEqual(t, written.Create.Nodes[0].ID, osm.NodeID(-1))
+	assertEqual(t, written.Modify.Nodes[0].Version, 3)
+	assertEqual(t, written.Modify.Nodes[0].Tags.Find("name"), "Gostilna")
+}
+
+func TestApplyMasterTags(t *testing.T) {
+	f := geojson.NewPointFeature([]float64{14.5, 46})
+	f.Properties = map[string]interface{}{tagHousenumber: "12", tagStreet: "Slovenska cesta", tagSource: "GURS", tagRef: "1001"}
+	tags := osm.Tags{{Key: tagHousenumber, Value: "12"}, {Key: tagStreet, Value: "Slovenska c."}, {Key: tagSource, Value: "survey"}}
+
+	modified, changed := applyMasterTags(tags, f, []string{tagHousenumber, tagStreet, tagPostCode}, DefaultTaggingProfile().Keys)
+	assertEqual(t, changed, true)
+	assertEqual(t, modified.Find(tagStreet), "Slovenska cesta")
+	// not master tags, or missing in the feature
+	assertEqual(t, modified.Find(tagSource), "survey")
+	assertEqual(t, modified.HasTag(tagRef), false)
+	assertEqual(t, modified.HasTag(tagPostCode), false)
+	// the object tags are not changed
+	assertEqual(t, tags.Find(tagStreet), "Slovenska c.")
+
+	_, changed = applyMasterTags(modified, f, []string{tagHousenumber, tagStreet}, DefaultTaggingProfile().Keys)
+	assertEqual(t, changed, false)
+}
@@ -0,0 +1,201 @@
+package gurs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/paulmach/osm"
+	"github.com/paulmach/osm/osmpbf"
+	"github.com/paulmach/osm/osmxml"
+)
+
+// OsmObject is a node, way or relation of an OSM extract with its centroid,
+// node coordinates of ways and of way members of relations are filled in
+type OsmObject struct {
+	Object   osm.Object
+	Tags     osm.Tags
+	Lon, Lat float64
+}
+
+// Type returns node, way or relation
+func (o *OsmObject) Type() osm.Type {
+	return o.Object.ObjectID().Type()
+}
+
+// Ref returns the id of the object, unique per type
+func (o *OsmObject) Ref() int64 {
+	return o.Object.ObjectID().Ref()
+}
+
+// String returns eg. "way/123"
+func (o *OsmObject) String() string {
+	return fmt.Sprintf("%s/%d", o.Type(), o.Ref())
+}
+
+// OsmExtract holds the selected objects of a local OSM extract
+type OsmExtract struct {
+	// nodes, ways and relations in file order
+	Objects []*OsmObject
+	// ways and relations without any node coordinates in the extract (eg. clipped at its border), left out
+	Incomplete int
+}
+
+// IsAddressed selects objects with the house number or ref key of the profile
+func (p *TaggingProfile) IsAddressed(tags osm.Tags) bool {
+	return tags.HasTag(p.Keys.Housenumber) || tags.HasTag(p.Keys.Ref)
+}
+
+// ReadOsmExtract reads objects selected by keep from an .osm or .osm.pbf file. The file is scanned three times:
+// relations first, then ways (kept ones and members of kept relations), then nodes (kept ones and nodes of those ways).
+func ReadOsmExtract(filename string, keep func(osm.Tags) bool) (*OsmExtract, error) {
+	var relations []*osm.Relation
+	memberWays := make(map[osm.WayID]osm.WayNodes)
+	err := scanOsmExtract(filename, osm.TypeRelation, func(o osm.Object) {
+		relation := o.(*osm.Relation)
+		if !keep(relation.Tags) {
+			return
+		}
+		relations = append(relations, relation)
+		for _, member := range relation.Members {
+			if member.Type == osm.TypeWay {
+				memberWays[osm.WayID(member.Ref)] = nil
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var ways []*osm.Way
+	nodeCoordinates := make(map[osm.NodeID][2]float64)
+	needNodes := func(wayNodes osm.WayNodes) {
+		for _, wayNode := range wayNodes {
+			nodeCoordinates[wayNode.ID] = [2]float64{}
+		}
+	}
+	err = scanOsmExtract(filename, osm.TypeWay, func(o osm.Object) {
+		way := o.(*osm.Way)
+		if _, member := memberWays[way.ID]; member {
+			memberWays[way.ID] = way.Nodes
+			needNodes(way.Nodes)
+		}
+		if keep(way.Tags) {
+			ways = append(ways, way)
+			needNodes(way.Nodes)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	extract := &OsmExtract{}
+	err = scanOsmExtract(filename, osm.TypeNode, func(o osm.Object) {
+		node := o.(*osm.Node)
+		if _, needed := nodeCoordinates[node.ID]; needed {
+			nodeCoordinates[node.ID] = [2]float64{node.Lon, node.Lat}
+		}
+		if keep(node.Tags) {
+			extract.Objects = append(extract.Objects, &OsmObject{Object: node, Tags: node.Tags, Lon: node.Lon, Lat: node.Lat})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// nodes missing in the extract are kept without coordinates, modified ways must not lose them
+	fillWayNodes := func(wayNodes osm.WayNodes) osm.WayNodes {
+		filled := make(osm.WayNodes, len(wayNodes))
+		for i, wayNode := range wayNodes {
+			coordinates := nodeCoordinates[wayNode.ID]
+			wayNode.Lon, wayNode.Lat = coordinates[0], coordinates[1]
+			filled[i] = wayNode
+		}
+		return filled
+	}
+
+	for _, way := range ways {
+		way.Nodes = fillWayNodes(way.Nodes)
+		if lon, lat, ok := wayNodesCentroid(way.Nodes); ok {
+			extract.Objects = append(extract.Objects, &OsmObject{Object: way, Tags: way.Tags, Lon: lon, Lat: lat})
+		} else {
+			extract.Incomplete++
+		}
+	}
+
+	for _, relation := range relations {
+		var wayNodes osm.WayNodes
+		for i, member := range relation.Members {
+			if member.Type == osm.TypeWay {
+				relation.Members[i].Nodes = fillWayNodes(memberWays[osm.WayID(member.Ref)])
+				wayNodes = append(wayNodes, relation.Members[i].Nodes...)
+			}
+		}
+		if lon, lat, ok := wayNodesCentroid(wayNodes); ok {
+			extract.Objects = append(extract.Objects, &OsmObject{Object: relation, Tags: relation.Tags, Lon: lon, Lat: lat})
+		} else {
+			extract.Incomplete++
+		}
+	}
+
+	return extract, nil
+}
+
+// wayNodesCentroid returns the average coordinates of the nodes found in the extract,
+// the closing node of a ring is counted once
+func wayNodesCentroid(wayNodes osm.WayNodes) (float64, float64, bool) {
+	if len(wayNodes) > 1 && wayNodes[0].ID == wayNodes[len(wayNodes)-1].ID {
+		wayNodes = wayNodes[:len(wayNodes)-1]
+	}
+
+	var lon, lat float64
+	count := 0
+	for _, wayNode := range wayNodes {
+		if wayNode.Lon == 0 && wayNode.Lat == 0 {
+			continue
+		}
+		lon += wayNode.Lon
+		lat += wayNode.Lat
+		count++
+	}
+	if count == 0 {
+		return 0, 0, false
+	}
+	return lon / float64(count), lat / float64(count), true
+}
+
+// scanOsmExtract calls fn with each object of the given type in the file
+func scanOsmExtract(filename string, objectType osm.Type, fn func(osm.Object)) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var scanner osm.Scanner
+	switch {
+	case strings.HasSuffix(filename, ".pbf"):
+		pbfScanner := osmpbf.New(context.Background(), file, runtime.GOMAXPROCS(-1))
+		pbfScanner.SkipNodes = objectType != osm.TypeNode
+		pbfScanner.SkipWays = objectType != osm.TypeWay
+		pbfScanner.SkipRelations = objectType != osm.TypeRelation
+		scanner = pbfScanner
+	case strings.HasSuffix(filename, ".osm"):
+		scanner = osmxml.New(context.Background(), file)
+	default:
+		return fmt.Errorf("%s: unknown OSM extract format, should be .osm or .osm.pbf", filename)
+	}
+	defer scanner.Close()
+
+	for scanner.Scan() {
+		if o := scanner.Object(); o.ObjectID().Type() == objectType {
+			fn(o)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading %s: %w", filename, err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package gurs
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWriteCategoryOSM(t *testing.T) {
+	_, featureCollections, _ := convertTestData(t, testRecords)
+
+	fileNamePattern := filepath.Join(t.TempDir(), "%s.osm")
+	options := OsmOptions{Upload: "never", Generator: DefaultOsmGenerator}
+	for _, category := range Categories(featureCollections) {
+		if err := WriteCategoryOSM(category, featureCollections[category], fileNamePattern, options); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rawXML, err := os.ReadFile(filepath.Join(filepath.Dir(fileNamePattern), "Ljubljana", "Zgornja_vas.osm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, strings.HasPrefix(string(rawXML), xml.Header), true)
+
+	var osm osmXML
+	if err := xml.Unmarshal(rawXML, &osm); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, osm.Version, "0.6")
+	assertEqual(t, osm.Upload, "never")
+	assertEqual(t, osm.Generator, DefaultOsmGenerator)
+	assertEqual(t, len(osm.Nodes), 2)
+	assertEqual(t, osm.Nodes[0].ID, int64(-1))
+	assertEqual(t, osm.Nodes[1].ID, int64(-2))
+
+	// same tags as in GeoJSON, sorted by key
+	f := featureCollections["Ljubljana/Zgornja_vas"].Features[0]
+	node := osm.Nodes[0]
+	assertEqual(t, len(node.Tags), len(f.Properties))
+	for i, tag := range node.Tags {
+		assertEqual(t, tag.Value, f.Properties[tag.Key])
+		if i > 0 && node.Tags[i-1].Key >= tag.Key {
+			t.Errorf("tags not sorted: %s >= %s", node.Tags[i-1].Key, tag.Key)
+		}
+	}
+	assertEqual(t, node.Lon, strconv.FormatFloat(f.Geometry.Point[0], 'f', -1, 64))
+
+	err = WriteCategoryOSM("x", featureCollections["Piran/Piran"], fileNamePattern, OsmOptions{Upload: "no"})
+	assertEqual(t, err != nil, true)
+	err = WriteCategoryOSM("x", featureCollections["Piran/Piran"], fileNamePattern, OsmOptions{Upload: "true"})
+	assertEqual(t, err.Error(), `invalid upload attribute "true", should be never, false or empty`)
+}
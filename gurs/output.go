@@ -0,0 +1,74 @@
+package gurs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	geojson "github.com/paulmach/go.geojson"
+)
+
+// Categories returns the keys of featureCollections, sorted alphabetically
+func Categories(featureCollections map[string]*geojson.FeatureCollection) []string {
+	categories := make([]string, 0, len(featureCollections))
+	for k := range featureCollections {
+		categories = append(categories, k)
+	}
+
+	sort.Strings(categories) //sort keys alphabetically
+	return categories
+}
+
+// WriteGeoJSON sorts (see SortFeatureCollection) and saves each category to its own file,
+// fileNamePattern should contain %s which is replaced by the category
+func WriteGeoJSON(featureCollections map[string]*geojson.FeatureCollection, fileNamePattern string, profile *TaggingProfile, order SortOrder) error {
+	for _, category := range Categories(featureCollections) {
+		if err := WriteCategoryGeoJSON(category, featureCollections[category], fileNamePattern, profile, order); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteCategoryGeoJSON sorts and saves one category, see WriteGeoJSON
+func WriteCategoryGeoJSON(category string, featureCollection *geojson.FeatureCollection, fileNamePattern string, profile *TaggingProfile, order SortOrder) error {
+	SortFeatureCollection(*featureCollection, profile, order)
+
+	catGeoJSONFileName := fmt.Sprintf(fileNamePattern, category)
+	rawJSON, err := json.MarshalIndent(featureCollection, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(catGeoJSONFileName)
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(catGeoJSONFileName, rawJSON, fs.FileMode(0644))
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Saved %d addresses to %s.", len(featureCollection.Features), catGeoJSONFileName)
+	return nil
+}
+
+// ReadGeoJSON reads a file saved by WriteGeoJSON
+func ReadGeoJSON(filename string) (*geojson.FeatureCollection, error) {
+	rawJSON, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	featureCollection, err := geojson.UnmarshalFeatureCollection(rawJSON)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return featureCollection, nil
+}
@@ -0,0 +1,187 @@
+package gurs
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Override is one row of overrides/<COLUMN>.csv: original,replacement[,mid[,reason]],
+// a header row naming the columns is optional and lines starting with # are comments
+type Override struct {
+	File string
+	Line int
+	// MID of the lookup row (UL_MID, NA_MID, PT_MID...), empty to replace Original in all rows
+	Mid string
+	// may be empty with Mid to replace any value of that row
+	Original    string
+	Replacement string
+	Reason      string
+	used        int
+}
+
+// overrideColumns in the order without a header row
+var overrideColumns = []string{"original", "replacement", "mid", "reason"}
+
+// overrides of one lookup column, rows with a MID take precedence over rows by value
+type overrides struct {
+	rows    []*Override
+	byValue map[string]*Override
+	byMid   map[string]*Override
+}
+
+// lookup returns the override for the value of the lookup row with mid, nil if none
+func (o *overrides) lookup(mid, value string) *Override {
+	if override, found := o.byMid[mid]; found && (override.Original == "" || override.Original == value) {
+		return override
+	}
+	return o.byValue[value]
+}
+
+// apply returns the replacement of value and counts the override as used
+func (o *overrides) apply(mid, value string) string {
+	if override := o.lookup(mid, value); override != nil {
+		override.used++
+		return override.Replacement
+	}
+	return value
+}
+
+// unused returns rows which matched no lookup row
+func (o *overrides) unused() []*Override {
+	var unused []*Override
+	for _, row := range o.rows {
+		if row.used == 0 {
+			unused = append(unused, row)
+		}
+	}
+	return unused
+}
+
+// isOverridesHeader returns true if the record names the original and replacement columns
+func isOverridesHeader(record []string) bool {
+	names := make(map[string]bool)
+	for _, column := range record {
+		names[strings.ToLower(strings.TrimSpace(column))] = true
+	}
+	return names["original"] && names["replacement"]
+}
+
+// readOverrides reads an overrides file, missing files are empty; duplicate rows
+// (same MID and original) are an error
+func readOverrides(filename string) (*overrides, error) {
+	result := &overrides{byValue: make(map[string]*Override), byMid: make(map[string]*Override)}
+
+	csvFile, err := os.Open(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		// overrides are optional
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer csvFile.Close()
+
+	reader := csv.NewReader(bufio.NewReader(csvFile))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+
+	columns := make(map[string]int)
+	for i, column := range overrideColumns {
+		columns[column] = i
+	}
+	var errs []error
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading overrides %s: %w", filename, err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		if first && isOverridesHeader(record) {
+			columns = make(map[string]int)
+			for i, column := range record {
+				columns[strings.ToLower(strings.TrimSpace(column))] = i
+			}
+			continue
+		}
+
+		field := func(column string) string {
+			if i, found := columns[column]; found && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+		row := &Override{File: filename, Line: line, Mid: strings.TrimSpace(field("mid")),
+			Original: field("original"), Replacement: field("replacement"), Reason: field("reason")}
+		switch {
+		case row.Replacement == "":
+			errs = append(errs, fmt.Errorf("%s:%d: no replacement", filename, line))
+			continue
+		case row.Original == "" && row.Mid == "":
+			errs = append(errs, fmt.Errorf("%s:%d: neither original value nor MID", filename, line))
+			continue
+		}
+
+		rows := result.byValue
+		key := row.Original
+		if row.Mid != "" {
+			rows, key = result.byMid, row.Mid
+		}
+		if previous, found := rows[key]; found {
+			errs = append(errs, fmt.Errorf("%s:%d: duplicate of line %d", filename, line, previous.Line))
+			continue
+		}
+		rows[key] = row
+		result.rows = append(result.rows, row)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UnusedOverrides returns override rows of all lookups which matched nothing in the last ReadLookups,
+// by file and line
+func (c *Converter) UnusedOverrides() []*Override {
+	var unused []*Override
+	for _, o := range c.overrides {
+		if o != nil {
+			unused = append(unused, o.unused()...)
+		}
+	}
+	return unused
+}
+
+// WriteOverridesCSV saves override rows with their file and line
+func WriteOverridesCSV(rows []*Override, filename string) error {
+	csvFile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer csvFile.Close()
+
+	writer := csv.NewWriter(csvFile)
+	if err := writer.Write([]string{"file", "line", "mid", "original", "replacement", "reason"}); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := writer.Write([]string{row.File, strconv.Itoa(row.Line), row.Mid, row.Original, row.Replacement, row.Reason}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return csvFile.Close()
+}
@@ -0,0 +1,106 @@
+package gurs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestOverrides(t *testing.T, dir, column, content string) string {
+	t.Helper()
+
+	filename := filepath.Join(dir, column+".csv")
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestReadOverrides(t *testing.T) {
+	dir := t.TempDir()
+
+	// old format: original,replacement
+	o, err := readOverrides(writeTestOverrides(t, dir, "NA_UIME", "Breg pri Ribnici na Dol.,Breg pri Ribnici na Dolenjskem\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, o.apply("1", "Breg pri Ribnici na Dol."), "Breg pri Ribnici na Dolenjskem")
+	assertEqual(t, o.apply("1", "Breg"), "Breg")
+
+	o, err = readOverrides(writeTestOverrides(t, dir, "UL_UIME", strings.Join([]string{
+		"# comments and a header",
+		"mid,original,replacement,reason",
+		",Nova loka,Nova Loka,capitalization",
+		"101,Nova loka,Nova loka,street in Ljubljana is lowercase",
+		"102,,Trg",
+		"",
+	}, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, len(o.rows), 3)
+	assertEqual(t, o.rows[0].Line, 3)
+	assertEqual(t, o.rows[0].Reason, "capitalization")
+	assertEqual(t, o.apply("100", "Nova loka"), "Nova Loka")
+	assertEqual(t, o.apply("101", "Nova loka"), "Nova loka")
+	assertEqual(t, o.apply("102", "Anything"), "Trg")
+	assertEqual(t, o.apply("103", "Anything"), "Anything")
+
+	_, err = readOverrides(writeTestOverrides(t, dir, "PT_UIME", strings.Join([]string{
+		"Ankaran - Ancarano,Ankaran / Ancarano",
+		"Hodoš - Hodos,Hodoš / Hodos",
+		"Ankaran - Ancarano,Ankaran",
+		"Koper - Capodistria,,11026975",
+		"Piran - Pirano,Piran / Pirano,11027123",
+		",Piran,11027123",
+	}, "\n")))
+	assertEqual(t, err.Error(), strings.Join([]string{
+		filepath.Join(dir, "PT_UIME.csv") + ":3: duplicate of line 1",
+		filepath.Join(dir, "PT_UIME.csv") + ":4: no replacement",
+		filepath.Join(dir, "PT_UIME.csv") + ":6: duplicate of line 5",
+	}, "\n"))
+
+	o, err = readOverrides(filepath.Join(dir, "OB_UIME.csv"))
+	assertEqual(t, err, nil)
+	assertEqual(t, len(o.rows), 0)
+}
+
+func TestUnusedOverrides(t *testing.T) {
+	dataDir, overridesDir := t.TempDir(), t.TempDir()
+	writeTestData(t, dataDir, testRecords)
+	writeTestOverrides(t, overridesDir, "UL_UIME", strings.Join([]string{
+		"Cesta slov. kmečkih uporov,Cesta slovenskih kmečkih uporov",
+		"Trg mladinskih delov. brigad,Trg mladinskih delovnih brigad",
+		",Stara cesta,999,street removed",
+	}, "\n"))
+	writeTestOverrides(t, overridesDir, "NA_UIME", "original,replacement,mid\nZgornja vas,Zgornja Vas,203\n")
+
+	converter, err := NewConverter(dataDir, overridesDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer converter.Close()
+	if err := converter.ReadLookups(); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, converter.ulNameMap["103"], "Cesta slovenskih kmečkih uporov")
+	assertEqual(t, converter.naNameMap["203"], "Zgornja Vas")
+
+	unused := converter.UnusedOverrides()
+	assertEqual(t, len(unused), 2)
+	assertEqual(t, unused[1].Mid, "999")
+	assertEqual(t, unused[0].Line, 2)
+	assertEqual(t, unused[0].Original, "Trg mladinskih delov. brigad")
+
+	filename := filepath.Join(t.TempDir(), "unused.csv")
+	if err := WriteOverridesCSV(unused[:1], filename); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, string(content), "file,line,mid,original,replacement,reason\n"+
+		filepath.Join(overridesDir, "UL_UIME.csv")+",2,,Trg mladinskih delov. brigad,Trg mladinskih delovnih brigad,\n")
+}
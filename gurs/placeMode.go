@@ -0,0 +1,81 @@
+package gurs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlaceMode selects how addresses without a street (UL_MID not in the UL lookup) name their settlement
+type PlaceMode string
+
+const (
+	// PlaceModePlace tags the settlement name as addr:place (with :sl/:it/:hu), the OSM convention for villages
+	PlaceModePlace PlaceMode = "place"
+	// PlaceModeStreet tags the settlement name as addr:street, as in the earlier conversions
+	PlaceModeStreet PlaceMode = "street"
+)
+
+// DefaultPlaceMode is used for municipalities without their own mode
+const DefaultPlaceMode = PlaceModeStreet
+
+// ParsePlaceMode returns the mode named s
+func ParsePlaceMode(s string) (PlaceMode, error) {
+	switch mode := PlaceMode(strings.TrimSpace(s)); mode {
+	case PlaceModePlace, PlaceModeStreet:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown place mode %q, should be %s or %s", s, PlaceModePlace, PlaceModeStreet)
+}
+
+// ParseMunicipalityPlaceModes parses comma separated municipality=mode pairs, municipalities by OB_MID or name,
+// eg. "Piran=street,Koper=street"
+func ParseMunicipalityPlaceModes(s string) (map[string]PlaceMode, error) {
+	modes := make(map[string]PlaceMode)
+	if strings.TrimSpace(s) == "" {
+		return modes, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		municipality, name, found := strings.Cut(pair, "=")
+		municipality = strings.TrimSpace(municipality)
+		if !found || municipality == "" {
+			return nil, fmt.Errorf("place mode %q should be municipality=mode", pair)
+		}
+		mode, err := ParsePlaceMode(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", municipality, err)
+		}
+		modes[municipality] = mode
+	}
+	return modes, nil
+}
+
+// resolvePlaceModes returns MunicipalityPlaceModes by OB_MID, unknown municipalities are an error
+func (c *Converter) resolvePlaceModes() (map[string]PlaceMode, error) {
+	modes := make(map[string]PlaceMode)
+	for municipality, mode := range c.MunicipalityPlaceModes {
+		found := false
+		for obMid, obName := range c.obNameMap {
+			if municipality == obMid || municipality == obName {
+				modes[obMid] = mode
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("place mode of unknown municipality %q", municipality)
+		}
+	}
+	return modes, nil
+}
+
+// placeTag returns the key of the settlement name of addresses without a street in the municipality,
+// the street key without a place key in the profile
+func (c *Converter) placeTag(obMid string) string {
+	mode, found := c.placeModes[obMid]
+	if !found {
+		mode = c.PlaceMode
+	}
+	if mode == PlaceModeStreet || c.Profile.Keys.Place == "" {
+		return c.Profile.Keys.Street
+	}
+	return c.Profile.Keys.Place
+}
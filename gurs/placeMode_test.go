@@ -0,0 +1,83 @@
+package gurs
+
+import (
+	"testing"
+
+	geojson "github.com/paulmach/go.geojson"
+	"github.com/paulmach/osm"
+)
+
+func TestParseMunicipalityPlaceModes(t *testing.T) {
+	modes, err := ParseMunicipalityPlaceModes("Piran=street, 301 = place")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, len(modes), 2)
+	assertEqual(t, modes["Piran"], PlaceModeStreet)
+	assertEqual(t, modes["301"], PlaceModePlace)
+
+	modes, err = ParseMunicipalityPlaceModes("")
+	assertEqual(t, err, nil)
+	assertEqual(t, len(modes), 0)
+
+	_, err = ParseMunicipalityPlaceModes("Piran")
+	assertEqual(t, err.Error(), `place mode "Piran" should be municipality=mode`)
+	_, err = ParseMunicipalityPlaceModes("Piran=village")
+	assertEqual(t, err.Error(), `Piran: unknown place mode "village", should be place or street`)
+}
+
+func TestPlaceModes(t *testing.T) {
+	dir := t.TempDir()
+	writeTestData(t, dir, testRecords)
+	converter := newTestConverter(t, dir)
+
+	// as in the earlier conversions unless selected
+	assertEqual(t, converter.PlaceMode, PlaceModeStreet)
+	featureCollections, err := converter.ReadShapefile(openTestHS(t, converter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zgornja := featureCollections["Ljubljana/Zgornja_vas"].Features
+	assertEqual(t, zgornja[0].Properties[tagRef], "1003")
+	assertEqual(t, zgornja[0].Properties[tagStreet], "Zgornja vas")
+	assertEqual(t, zgornja[0].Properties[tagPlace], nil)
+
+	converter.PlaceMode = PlaceModeStreet
+	converter.MunicipalityPlaceModes = map[string]PlaceMode{"Ljubljana": PlaceModePlace}
+	featureCollections, err = converter.ReadShapefile(openTestHS(t, converter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zgornja = featureCollections["Ljubljana/Zgornja_vas"].Features
+	assertEqual(t, zgornja[0].Properties[tagPlace], "Zgornja vas")
+	// streets are not affected
+	assertEqual(t, zgornja[1].Properties[tagStreet], "Cesta slovenskih kmečkih uporov")
+
+	converter.MunicipalityPlaceModes = map[string]PlaceMode{"Atlantida": PlaceModePlace}
+	_, err = converter.ReadShapefile(openTestHS(t, converter))
+	assertEqual(t, err.Error(), `place mode of unknown municipality "Atlantida"`)
+}
+
+func TestApplyMasterTagsPlace(t *testing.T) {
+	f := geojson.NewPointFeature([]float64{15, 46})
+	f.Properties = map[string]interface{}{tagHousenumber: "5", tagPlace: "Dobrovnik / Dobronak",
+		tagPlace + ":sl": "Dobrovnik", tagPlace + ":hu": "Dobronak"}
+
+	tags := osm.Tags{{Key: tagHousenumber, Value: "5"}, {Key: tagStreet, Value: "Dobrovnik / Dobronak"},
+		{Key: tagStreet + ":sl", Value: "Dobrovnik"}, {Key: tagStreet + ":hu", Value: "Dobronak"}}
+	// master tags for -place-mode place
+	placeMasterTags := append([]string{tagPlace}, DefaultMasterTags...)
+	modified, changed := applyMasterTags(tags, f, placeMasterTags, DefaultTaggingProfile().Keys)
+	assertEqual(t, changed, true)
+	assertEqual(t, len(modified), 2)
+	assertEqual(t, modified.Find(tagPlace), "Dobrovnik / Dobronak")
+
+	// a different street is kept, as is the street if addr:street is not a master tag
+	tags = osm.Tags{{Key: tagHousenumber, Value: "5"}, {Key: tagStreet, Value: "Glavna ulica"}}
+	modified, _ = applyMasterTags(tags, f, placeMasterTags, DefaultTaggingProfile().Keys)
+	assertEqual(t, modified.Find(tagStreet), "Glavna ulica")
+	tags = osm.Tags{{Key: tagHousenumber, Value: "5"}, {Key: tagStreet, Value: "Dobrovnik / Dobronak"}}
+	modified, changed = applyMasterTags(tags, f, []string{tagHousenumber, tagPlace}, DefaultTaggingProfile().Keys)
+	assertEqual(t, changed, true)
+	assertEqual(t, modified.Find(tagStreet), "Dobrovnik / Dobronak")
+}
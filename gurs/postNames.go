@@ -0,0 +1,69 @@
+package gurs
+
+import (
+	"strings"
+)
+
+// separator of the parts of hyphenated post names, eg. "Ljubljana - Šmartno" or "Piran - Pirano"
+const postNameHyphen = " - "
+
+// postName is a PT_UIME name with its Slovenian and minority language names if bilingual
+type postName struct {
+	// addr:city, bilingual names joined with bilingualSeparator
+	name                string
+	slovenian, minority string
+	// more than two names or an empty one, kept whole
+	odd bool
+	// hyphenated name kept whole as it is not bilingual
+	hyphenated bool
+}
+
+// parsePostName splits "Slovenian / minority" names, and hyphenated names if isBilingual
+// recognises the parts as the names of a bilingual settlement or area
+func parsePostName(name string, isBilingual func(slovenian, minority string) bool) postName {
+	post := postName{name: name}
+	separator := bilingualSeparator
+	if !strings.Contains(name, bilingualSeparator) {
+		if !strings.Contains(name, postNameHyphen) {
+			return post
+		}
+		separator = postNameHyphen
+	}
+
+	parts := strings.Split(name, separator)
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+		if parts[i] == "" {
+			post.odd = true
+		}
+	}
+	switch {
+	case separator == postNameHyphen && (post.odd || len(parts) != 2 || !isBilingual(parts[0], parts[1])):
+		post.odd = false
+		post.hyphenated = true
+	case post.odd || len(parts) != 2:
+		post.odd = true
+	default:
+		post.slovenian, post.minority = parts[0], parts[1]
+		post.name = post.slovenian + bilingualSeparator + post.minority
+	}
+	return post
+}
+
+// parsePostNames parses the names of all posts, hyphenated names are bilingual if the first part is a declared
+// area or the parts are the names of a settlement with a bilingual name, eg. "Portorož - Portorose"
+func (c *Converter) parsePostNames() map[string]postName {
+	settlements := make(map[[2]string]bool)
+	for naMid, naNameDj := range c.naNameDjMap {
+		settlements[[2]string{c.naNameMap[naMid], naNameDj}] = true
+	}
+	isBilingual := func(slovenian, minority string) bool {
+		return c.bilingual.declares(slovenian) || settlements[[2]string{slovenian, minority}]
+	}
+
+	posts := make(map[string]postName, len(c.ptNameMap))
+	for ptMid, ptName := range c.ptNameMap {
+		posts[ptMid] = parsePostName(ptName, isBilingual)
+	}
+	return posts
+}
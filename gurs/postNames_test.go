@@ -0,0 +1,68 @@
+package gurs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParsePostName(t *testing.T) {
+	isBilingual := func(slovenian, minority string) bool {
+		return slovenian == "Piran" || slovenian+minority == "PortorožPortorose"
+	}
+	tests := []struct {
+		name                      string
+		want, slovenian, minority string
+		odd, hyphenated           bool
+	}{
+		{"Ljubljana", "Ljubljana", "", "", false, false},
+		{"Koper / Capodistria", "Koper / Capodistria", "Koper", "Capodistria", false, false},
+		{"Piran - Pirano", "Piran / Pirano", "Piran", "Pirano", false, false},
+		{"Portorož - Portorose", "Portorož / Portorose", "Portorož", "Portorose", false, false},
+		{"Ljubljana - Šmartno", "Ljubljana - Šmartno", "", "", false, true},
+		{"Rateče - Planica", "Rateče - Planica", "", "", false, true},
+		{"Piran - Pirano - Piranum", "Piran - Pirano - Piranum", "", "", false, true},
+		{"Piran - ", "Piran - ", "", "", false, true},
+		{"Koper / Capodistria / Koper", "Koper / Capodistria / Koper", "", "", true, false},
+		{"Koper / ", "Koper / ", "", "", true, false},
+	}
+	for _, test := range tests {
+		post := parsePostName(test.name, isBilingual)
+		assertEqual(t, post.name, test.want)
+		assertEqual(t, post.slovenian, test.slovenian)
+		assertEqual(t, post.minority, test.minority)
+		assertEqual(t, post.odd, test.odd)
+		assertEqual(t, post.hyphenated, test.hyphenated)
+	}
+}
+
+func TestPostNameChecks(t *testing.T) {
+	dataDir, overridesDir := t.TempDir(), t.TempDir()
+	writeTestData(t, dataDir, testRecords)
+	writeTestOverrides(t, overridesDir, "PT_UIME", "Piran - Pirano,Piran / Pirano / Piranum\nLjubljana,Ljubljana - Šmartno\n")
+	if err := os.WriteFile(filepath.Join(overridesDir, BilingualAreasFile), []byte("it,Piran,\nit,Ljubljana,Zgornja vas\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	converter := newTestConverter(t, dataDir)
+	converter.overridesDir = overridesDir
+	if err := converter.ReadLookups(); err != nil {
+		t.Fatal(err)
+	}
+	featureCollections, err := converter.ReadShapefile(openTestHS(t, converter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	piran := featureCollections["Piran/Piran"].Features[0]
+	assertEqual(t, piran.Properties[tagCity], "Piran / Pirano / Piranum")
+	assertEqual(t, piran.Properties[tagCity+":sl"], nil)
+	assertEqual(t, featureCollections["Ljubljana/Zgornja_vas"].Features[0].Properties[tagCity], "Ljubljana - Šmartno")
+
+	checks := converter.BilingualChecks()
+	assertEqual(t, len(checks), 3)
+	assertEqual(t, checks[0].Problem, bilingualPostParts)
+	assertEqual(t, checks[0].Mid, "11027123")
+	assertEqual(t, checks[1].Problem, bilingualPostHyphen)
+	assertEqual(t, checks[1].Settlement, "Zgornja vas")
+	assertEqual(t, checks[1].Records, 2)
+	assertEqual(t, checks[2].Problem, bilingualUnusedArea)
+}
@@ -0,0 +1,121 @@
+package gurs
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+
+	geojson "github.com/paulmach/go.geojson"
+	"github.com/paulmach/osm"
+)
+
+// preview actions besides create, modify, delete and retag
+const (
+	actionNone      = "none"
+	actionDuplicate = "duplicate"
+	actionUnmatched = "unmatched"
+)
+
+// Preview returns all conflated objects as GeoJSON points for review, like the -preview.geojson of the Python conflator:
+// the action, osm_type and osm_id properties, tags.* for kept, tags_new.*, tags_changed.* ("old --> new")
+// and tags_deleted.* for changed tags, matched_by (ref or distance), ref_coords and ref_distance of the matched GURS address
+func (conflation *Conflation) Preview() *geojson.FeatureCollection {
+	preview := geojson.NewFeatureCollection()
+
+	for _, match := range conflation.Matched {
+		action := actionNone
+		if match.Changed {
+			action = "modify"
+		}
+		f := previewObject(match.Object, action, match.Tags)
+		if match.ByRef {
+			f.SetProperty("matched_by", "ref")
+		} else {
+			f.SetProperty("matched_by", "distance")
+		}
+		f.SetProperty("ref_coords", match.Feature.Geometry.Point)
+		f.SetProperty("ref_distance", math.Round(match.Distance*10)/10)
+		preview.AddFeature(f)
+	}
+
+	for _, match := range conflation.Attached {
+		f := previewObject(match.Object, "modify", match.Tags)
+		f.SetProperty("matched_by", "building")
+		f.SetProperty("ref_coords", match.Feature.Geometry.Point)
+		preview.AddFeature(f)
+	}
+
+	unattached := make(map[*geojson.Feature]BuildingReason)
+	for _, report := range conflation.Unattached {
+		unattached[report.Feature] = report.Reason
+	}
+	for _, feature := range conflation.UnmatchedDataset {
+		f := previewFeature(feature, "create")
+		if reason, found := unattached[feature]; found {
+			f.SetProperty("building", string(reason))
+		}
+		preview.AddFeature(f)
+	}
+	for _, feature := range conflation.UnmatchedDuplicates {
+		preview.AddFeature(previewFeature(feature, actionDuplicate))
+	}
+
+	for _, o := range conflation.UnmatchedOSM {
+		switch conflation.unmatchedAction(o) {
+		case actionDelete:
+			preview.AddFeature(previewObject(o, actionDelete, nil))
+		case actionRetag:
+			preview.AddFeature(previewObject(o, actionRetag, conflation.options.Profile.withoutAddressTags(o.Tags)))
+		default:
+			preview.AddFeature(previewObject(o, actionUnmatched, o.Tags))
+		}
+	}
+	return preview
+}
+
+func previewFeature(feature *geojson.Feature, action string) *geojson.Feature {
+	f := geojson.NewPointFeature(feature.Geometry.Point)
+	f.SetProperty("action", action)
+	for key, value := range feature.Properties {
+		f.SetProperty("tags."+key, value)
+	}
+	return f
+}
+
+// previewObject returns the object centroid with tag changes from its current tags to newTags
+func previewObject(o *OsmObject, action string, newTags osm.Tags) *geojson.Feature {
+	f := geojson.NewPointFeature([]float64{round(o.Lon), round(o.Lat)})
+	f.SetProperty("action", action)
+	f.SetProperty("osm_type", string(o.Type()))
+	f.SetProperty("osm_id", o.Ref())
+
+	oldTags := o.Tags.Map()
+	newTagsMap := newTags.Map()
+	for key, value := range newTagsMap {
+		oldValue, found := oldTags[key]
+		switch {
+		case !found:
+			f.SetProperty("tags_new."+key, value)
+		case oldValue != value:
+			f.SetProperty("tags_changed."+key, fmt.Sprintf("%s --> %s", oldValue, value))
+		default:
+			f.SetProperty("tags."+key, value)
+		}
+	}
+	for key, value := range oldTags {
+		if _, found := newTagsMap[key]; !found {
+			f.SetProperty("tags_deleted."+key, value)
+		}
+	}
+	return f
+}
+
+// WritePreview saves the preview GeoJSON
+func WritePreview(preview *geojson.FeatureCollection, filename string) error {
+	rawJSON, err := json.MarshalIndent(preview, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, rawJSON, 0644)
+}
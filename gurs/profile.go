@@ -0,0 +1,149 @@
+package gurs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// DefaultTaggingProfileFile is the profile of the default keys, see DefaultTaggingProfile
+const DefaultTaggingProfileFile = "taggingProfile.json"
+
+// TagKeys are the OSM keys of the GURS values, optional values with an empty key are left out
+type TagKeys struct {
+	// HS label (HS_STEV + HS_DSTEV)
+	Housenumber string `json:"housenumber"`
+	// street name (UL_UIME, UL_DJ)
+	Street string `json:"street"`
+	// optional, settlement name (NA_UIME, NA_DJ) of addresses without a street, see PlaceMode;
+	// without it the settlement is the street
+	Place string `json:"place,omitempty"`
+	// optional, settlement name if not in the street or post name
+	Village string `json:"village,omitempty"`
+	// post name (PT_UIME)
+	City string `json:"city"`
+	// post code (PT_ID)
+	Postcode string `json:"postcode"`
+	// optional, SourceValue
+	Source string `json:"source,omitempty"`
+	// optional, date of the HS record (D_OD)
+	SourceDate string `json:"source_date,omitempty"`
+	// HS_MID, identifies the addresses in the conflation and diff
+	Ref string `json:"ref"`
+}
+
+// TaggingProfile maps GURS values to OSM keys, shared by the converter, the output writers and the conflation
+type TaggingProfile struct {
+	Keys TagKeys `json:"keys"`
+	// bilingual names are also tagged with :sl and :it or :hu keys
+	Languages bool `json:"languages"`
+	// value of the Source key
+	SourceValue string `json:"source_value,omitempty"`
+	// names of the Keys fields (json) replaced on matched OSM objects, see ConflationOptions.MasterTags
+	MasterTags []string `json:"master_tags"`
+}
+
+// DefaultTaggingProfile returns the profile of the tags used so far
+func DefaultTaggingProfile() *TaggingProfile {
+	return &TaggingProfile{
+		Keys: TagKeys{
+			Housenumber: tagHousenumber,
+			Street:      tagStreet,
+			Place:       tagPlace,
+			Village:     tagVillage,
+			City:        tagCity,
+			Postcode:    tagPostCode,
+			Source:      tagSource,
+			SourceDate:  tagSourceDate,
+			Ref:         tagRef,
+		},
+		Languages:   true,
+		SourceValue: tagSourceValue,
+		MasterTags:  []string{"housenumber", "street", "postcode", "village", "city"},
+	}
+}
+
+// ReadTaggingProfile reads a JSON profile, unknown fields, missing required keys
+// and unknown master tags are an error
+func ReadTaggingProfile(filename string) (*TaggingProfile, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	var profile TaggingProfile
+	if err := decoder.Decode(&profile); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if err := profile.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return &profile, nil
+}
+
+// tagKeyNames are the json field names of TagKeys in the order of taginfo.json
+var tagKeyNames = []string{"housenumber", "street", "place", "village", "postcode", "city", "source", "source_date", "ref"}
+
+// byName returns the keys by their json field names
+func (k TagKeys) byName() map[string]string {
+	return map[string]string{
+		"housenumber": k.Housenumber,
+		"street":      k.Street,
+		"place":       k.Place,
+		"village":     k.Village,
+		"city":        k.City,
+		"postcode":    k.Postcode,
+		"source":      k.Source,
+		"source_date": k.SourceDate,
+		"ref":         k.Ref,
+	}
+}
+
+func (p *TaggingProfile) validate() error {
+	var errs []error
+	required := map[string]string{"housenumber": p.Keys.Housenumber, "street": p.Keys.Street,
+		"city": p.Keys.City, "postcode": p.Keys.Postcode, "ref": p.Keys.Ref}
+	for _, name := range []string{"housenumber", "street", "city", "postcode", "ref"} {
+		if required[name] == "" {
+			errs = append(errs, fmt.Errorf("no key of the required %s", name))
+		}
+	}
+
+	seen := make(map[string]string)
+	keys := p.Keys.byName()
+	for _, name := range tagKeyNames {
+		key := keys[name]
+		if key == "" {
+			continue
+		}
+		if previous, found := seen[key]; found {
+			errs = append(errs, fmt.Errorf("%s has the same key %s as %s", name, key, previous))
+		}
+		seen[key] = name
+	}
+
+	if p.Keys.Source != "" && p.SourceValue == "" {
+		errs = append(errs, errors.New("no source_value of the source key"))
+	}
+	for _, name := range p.MasterTags {
+		if key, found := keys[name]; !found {
+			errs = append(errs, fmt.Errorf("unknown master tag %q, should be one of the keys", name))
+		} else if key == "" {
+			errs = append(errs, fmt.Errorf("master tag %s is disabled", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MasterKeys returns the OSM keys of the master tags
+func (p *TaggingProfile) MasterKeys() []string {
+	keys := p.Keys.byName()
+	masterKeys := make([]string, 0, len(p.MasterTags))
+	for _, name := range p.MasterTags {
+		masterKeys = append(masterKeys, keys[name])
+	}
+	return masterKeys
+}
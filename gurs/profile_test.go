@@ -0,0 +1,86 @@
+package gurs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/paulmach/osm"
+)
+
+func TestDefaultTaggingProfileMatchesFile(t *testing.T) {
+	profile, err := ReadTaggingProfile(filepath.Join("..", DefaultTaggingProfileFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, reflect.DeepEqual(profile, DefaultTaggingProfile()), true)
+}
+
+func TestReadTaggingProfile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "profile.json")
+	read := func(content string) error {
+		t.Helper()
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := ReadTaggingProfile(filename)
+		return err
+	}
+
+	err := read(`{"keys": {"housenumber": "addr:housenumber", "street": "addr:street", "city": "addr:street",
+		"postcode": "addr:postcode", "source": "source:addr"}, "master_tags": ["housenumber", "country", "village"]}`)
+	assertEqual(t, strings.ReplaceAll(err.Error(), filename+": ", ""), strings.Join([]string{
+		"no key of the required ref",
+		"city has the same key addr:street as street",
+		"no source_value of the source key",
+		`unknown master tag "country", should be one of the keys`,
+		"master tag village is disabled",
+	}, "\n"))
+
+	err = read(`{"keys": {"housenumber": "addr:housenumber"}, "language": true}`)
+	assertEqual(t, err.Error(), filename+`: json: unknown field "language"`)
+}
+
+func TestTaggingProfile(t *testing.T) {
+	dir := t.TempDir()
+	writeTestData(t, dir, testRecords)
+	converter := newTestConverter(t, dir)
+	converter.Profile = &TaggingProfile{
+		Keys: TagKeys{Housenumber: "addr:housenumber", Street: "addr:street", City: "addr:city",
+			Postcode: "addr:postcode", Ref: "ref:GURS:HS_MID"},
+		MasterTags: []string{"housenumber", "street"},
+	}
+	featureCollections, err := converter.ReadShapefile(openTestHS(t, converter))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without place key the settlement is the street, no optional or language tags
+	zgornja := featureCollections["Ljubljana/Zgornja_vas"]
+	SortFeatureCollection(*zgornja, converter.Profile, DefaultSortOrder)
+	assertEqual(t, zgornja.Features[0].Properties["ref:GURS:HS_MID"], "1004")
+	assertEqual(t, len(zgornja.Features[0].Properties), 5)
+	assertEqual(t, zgornja.Features[1].Properties[tagStreet], "Zgornja vas")
+	piran := featureCollections["Piran/Piran"].Features[0]
+	assertEqual(t, piran.Properties[tagStreet], "Tartinijev trg / Piazza Giuseppe Tartini")
+	assertEqual(t, len(piran.Properties), 5)
+
+	// the conflation finds objects by the ref of the profile
+	extract, err := ReadOsmExtract(writeTestExtract(t, osm.OSM{Nodes: osm.Nodes{
+		{ID: 1, Version: 1, Visible: true, Lon: 15, Lat: 46, Tags: osm.Tags{{Key: "ref:GURS:HS_MID", Value: "1004"}}},
+		{ID: 2, Version: 1, Visible: true, Lon: 15, Lat: 46, Tags: osm.Tags{{Key: tagRef, Value: "1003"}}},
+	}}), converter.Profile.IsAddressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, len(extract.Objects), 1)
+	options := DefaultConflationOptions()
+	options.Profile, options.MasterTags = converter.Profile, converter.Profile.MasterKeys()
+	conflation := NewConflator(extract, options).Conflate(zgornja)
+	assertEqual(t, len(conflation.Matched), 1)
+	assertEqual(t, conflation.Matched[0].ByRef, true)
+	assertEqual(t, conflation.Matched[0].Tags.Find(tagStreet), "Cesta slovenskih kmečkih uporov")
+	assertEqual(t, conflation.Matched[0].Tags.HasTag(tagPostCode), false)
+}
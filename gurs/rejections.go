@@ -0,0 +1,184 @@
+package gurs
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	geojson "github.com/paulmach/go.geojson"
+)
+
+// Reason describes why a HS record was rejected or degraded
+type Reason string
+
+// Rejected records are left out of the output, degraded ones are converted with missing tags
+const (
+	ReasonInvalidStatus       Reason = "invalid_status"       // rejected: STATUS is not V (veljavno)
+	ReasonEmptyLabel          Reason = "empty_labela"         // rejected: no house number
+	ReasonInvalidGeometry     Reason = "invalid_geometry"     // rejected: not a point or outside of Slovenia
+	ReasonMissingStreet       Reason = "missing_street"       // degraded: UL_MID not found in UL
+	ReasonMissingSettlement   Reason = "missing_settlement"   // degraded: NA_MID not found in NA
+	ReasonMissingPost         Reason = "missing_post"         // degraded: PT_MID not found in PT
+	ReasonMissingMunicipality Reason = "missing_municipality" // degraded: OB_MID not found in OB
+	ReasonInvalidDate         Reason = "invalid_date"         // degraded: D_OD is blank or not a date, no source:addr:date
+)
+
+// Rejection is a single rejected or degraded HS record with its raw attributes
+type Rejection struct {
+	HsMid    string
+	Reason   Reason
+	Rejected bool
+	// nil if the geometry could not be read or transformed
+	Point      []float64
+	Attributes []string
+}
+
+// Rejections counts rejected and degraded records of one HS shapefile by reason,
+// the records are written to the report files as they are added and not kept in memory
+type Rejections struct {
+	// DBF column names of Attributes
+	Columns []string
+	// number of all HS records read
+	Total int
+	// number of rows in the report, one per reason of a record
+	Rows int
+
+	counts          map[Reason]int
+	affectedRecords int
+
+	// report files, nil without a basename
+	csvFile, geoJSONFile *os.File
+	csvWriter            *csv.Writer
+	geoJSONWriter        *bufio.Writer
+}
+
+// newRejections creates <basename>.geojson and <basename>.csv, only counts the records if basename is empty
+func newRejections(columns []string, basename string) (*Rejections, error) {
+	r := &Rejections{Columns: columns, counts: make(map[Reason]int)}
+	if basename == "" {
+		return r, nil
+	}
+
+	var err error
+	if r.csvFile, err = os.Create(basename + ".csv"); err != nil {
+		return nil, err
+	}
+	if r.geoJSONFile, err = os.Create(basename + ".geojson"); err != nil {
+		r.csvFile.Close()
+		return nil, err
+	}
+	r.csvWriter = csv.NewWriter(r.csvFile)
+	r.geoJSONWriter = bufio.NewWriter(r.geoJSONFile)
+	if err := r.csvWriter.Write(append([]string{"hs_mid", "reason", "rejected", "lon", "lat"}, r.Columns...)); err != nil {
+		r.close()
+		return nil, err
+	}
+	if _, err := r.geoJSONWriter.WriteString(`{"type":"FeatureCollection","features":[`); err != nil {
+		r.close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// add counts all reasons of one HS record and writes them to the report files
+func (r *Rejections) add(hsMid string, point []float64, attributes []string, rejected bool, reasons ...Reason) error {
+	if len(reasons) == 0 {
+		return nil
+	}
+	r.affectedRecords++
+	for _, reason := range reasons {
+		r.counts[reason]++
+		if err := r.write(Rejection{HsMid: hsMid, Reason: reason, Rejected: rejected, Point: point, Attributes: attributes}); err != nil {
+			return err
+		}
+		r.Rows++
+	}
+	return nil
+}
+
+// Count returns the number of records with the given reason
+func (r *Rejections) Count(reason Reason) int {
+	return r.counts[reason]
+}
+
+// Summary returns per reason counts, one reason per line
+func (r *Rejections) Summary() string {
+	reasons := make([]string, 0, len(r.counts))
+	for reason := range r.counts {
+		reasons = append(reasons, string(reason))
+	}
+	sort.Strings(reasons)
+
+	lines := []string{fmt.Sprintf("%d of %d HS records rejected or degraded", r.affectedRecords, r.Total)}
+	for _, reason := range reasons {
+		lines = append(lines, fmt.Sprintf("  %s: %d", reason, r.counts[Reason(reason)]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CheckThreshold returns an error if more than maxRatio (0..1) of all records were rejected or degraded,
+// maxRatio 0 disables the check
+func (r *Rejections) CheckThreshold(maxRatio float64) error {
+	if maxRatio <= 0 || r.Total == 0 {
+		return nil
+	}
+	if ratio := float64(r.affectedRecords) / float64(r.Total); ratio > maxRatio {
+		return fmt.Errorf("%d of %d HS records (%.2f%%) rejected or degraded, more than allowed %.2f%%",
+			r.affectedRecords, r.Total, ratio*100, maxRatio*100)
+	}
+	return nil
+}
+
+// write appends one row to the report files
+func (r *Rejections) write(rejection Rejection) error {
+	if r.csvWriter == nil {
+		return nil
+	}
+
+	f := &geojson.Feature{Type: "Feature", Properties: make(map[string]interface{})}
+	lon, lat := "", ""
+	if rejection.Point != nil {
+		f.Geometry = geojson.NewPointGeometry(rejection.Point)
+		lon = strconv.FormatFloat(rejection.Point[0], 'f', -1, 64)
+		lat = strconv.FormatFloat(rejection.Point[1], 'f', -1, 64)
+	}
+	f.SetProperty("hs_mid", rejection.HsMid)
+	f.SetProperty("reason", string(rejection.Reason))
+	f.SetProperty("rejected", rejection.Rejected)
+	for i, column := range r.Columns {
+		f.SetProperty(column, rejection.Attributes[i])
+	}
+	rawJSON, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if r.Rows > 0 {
+		r.geoJSONWriter.WriteByte(',')
+	}
+	r.geoJSONWriter.WriteByte('\n')
+	if _, err := r.geoJSONWriter.Write(rawJSON); err != nil {
+		return err
+	}
+
+	row := append([]string{rejection.HsMid, string(rejection.Reason), strconv.FormatBool(rejection.Rejected), lon, lat}, rejection.Attributes...)
+	return r.csvWriter.Write(row)
+}
+
+// close completes and closes the report files
+func (r *Rejections) close() error {
+	if r.csvWriter == nil {
+		return nil
+	}
+
+	r.csvWriter.Flush()
+	r.geoJSONWriter.WriteString("\n]}\n")
+	err := errors.Join(r.csvWriter.Error(), r.geoJSONWriter.Flush(), r.csvFile.Close(), r.geoJSONFile.Close())
+	r.csvWriter, r.geoJSONWriter = nil, nil
+	return err
+}
@@ -0,0 +1,118 @@
+package gurs
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	shp "github.com/jonas-p/go-shp"
+	geojson "github.com/paulmach/go.geojson"
+)
+
+func TestRejections(t *testing.T) {
+	records := append([]hsTestRecord{
+		{"2001", "1", "101", "201", "301", "99999999", "V", 461800, 101300}, // unknown post
+		{"2002", "", "101", "201", "301", "11026975", "V", 461800, 101300},  // no house number
+		{"2003", "2", "101", "201", "301", "11026975", "V", 900000, 101300}, // outside Slovenia
+		{"2004", "4", "999", "299", "399", "11026975", "V", 461900, 101400}, // unknown street, settlement and municipality
+	}, testRecords...)
+
+	converter, _, features := convertTestData(t, records)
+	rejections := converter.Rejections()
+
+	assertEqual(t, rejections.Total, 9)
+	assertEqual(t, rejections.Count(ReasonInvalidStatus), 1)
+	assertEqual(t, rejections.Count(ReasonEmptyLabel), 1)
+	assertEqual(t, rejections.Count(ReasonInvalidGeometry), 1)
+	assertEqual(t, rejections.Count(ReasonMissingPost), 1)
+	assertEqual(t, rejections.Count(ReasonMissingStreet), 1)
+	assertEqual(t, rejections.Count(ReasonMissingSettlement), 1)
+	assertEqual(t, rejections.Count(ReasonMissingMunicipality), 1)
+
+	// degraded records are still converted, rejected are not
+	if features["2001"] == nil || features["2004"] == nil {
+		t.Error("degraded records should be converted")
+	}
+	for _, hsMid := range []string{"2002", "2003", "1005"} {
+		if features[hsMid] != nil {
+			t.Errorf("rejected record %s should not be converted", hsMid)
+		}
+	}
+
+	if !strings.HasPrefix(rejections.Summary(), "5 of 9 HS records rejected or degraded\n  empty_labela: 1\n") {
+		t.Errorf("unexpected summary:\n%s", rejections.Summary())
+	}
+	if err := rejections.CheckThreshold(0.6); err != nil {
+		t.Error(err)
+	}
+	if err := rejections.CheckThreshold(0.5); err == nil {
+		t.Error("5 of 9 should be more than 50%")
+	}
+	if err := rejections.CheckThreshold(0); err != nil {
+		t.Error(err)
+	}
+
+	assertEqual(t, rejections.Rows, 7)
+	basename := converter.RejectionsBasename
+	csvFile, err := os.Open(basename + ".csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer csvFile.Close()
+	rows, err := csv.NewReader(csvFile).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, len(rows), 8)
+	assertEqual(t, strings.Join(rows[0][:6], ","), "hs_mid,reason,rejected,lon,lat,ENOTA")
+	assertEqual(t, strings.Join(rows[1][:3], ","), "2001,missing_post,false")
+	assertEqual(t, rows[1][6], "2001")
+	rawJSON, err := os.ReadFile(basename + ".geojson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	featureCollection, err := geojson.UnmarshalFeatureCollection(rawJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, len(featureCollection.Features), 7)
+	assertEqual(t, featureCollection.Features[0].Properties["reason"], "missing_post")
+	assertEqual(t, featureCollection.Features[0].Properties["ENOTA"], "HS")
+}
+
+func TestRejectionsInvalidDate(t *testing.T) {
+	dir := t.TempDir()
+	writeTestData(t, dir, nil)
+	// D_OD blank, too short, not a date and valid
+	var points []shp.Point
+	var rows [][]string
+	for i, dOd := range []string{"", "2024", "2024XX15", "20240115"} {
+		points = append(points, shp.Point{X: 461755.9762 + float64(i), Y: 101252.0983})
+		rows = append(rows, []string{"HS", strconv.Itoa(3001 + i), "", "", "1", "101", "201", "301", "11026975", "", dOd, "20240116", "V", "", ""})
+	}
+	writeTestShapefile(t, dir, "HS", schemaFields(hsSchema), points, rows)
+
+	converter := newTestConverter(t, dir)
+	converter.RejectionsBasename = filepath.Join(t.TempDir(), "rejected")
+	featureCollections, err := converter.ReadShapefile(openTestHS(t, converter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	features := featureCollections["Ljubljana/Ljubljana"].Features
+	assertEqual(t, len(features), 4)
+	for _, f := range features {
+		_, dated := f.Properties[tagSourceDate]
+		assertEqual(t, dated, f.Properties[tagRef] == "3004")
+	}
+
+	rejections := converter.Rejections()
+	assertEqual(t, rejections.Count(ReasonInvalidDate), 3)
+	rawCSV, err := os.ReadFile(converter.RejectionsBasename + ".csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, strings.Count(string(rawCSV), ",invalid_date,false,"), 3)
+}
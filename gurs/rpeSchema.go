@@ -0,0 +1,163 @@
+package gurs
+
+import (
+	"fmt"
+	"strings"
+
+	shp "github.com/jonas-p/go-shp"
+)
+
+// rpeField describes one DBF column as declared in RPE_struktura.docx
+type rpeField struct {
+	name      string
+	fieldType byte
+	size      uint8
+	precision uint8
+	// alternative names used by some exports (eg. D48/GK instead of D96/TM coordinates)
+	aliases []string
+}
+
+func (f rpeField) String() string {
+	return fmt.Sprintf("%s %s", f.name, formatFieldType(f.fieldType, f.size, f.precision))
+}
+
+func formatFieldType(fieldType byte, size, precision uint8) string {
+	if fieldType == 'N' || fieldType == 'F' {
+		return fmt.Sprintf("%c(%d.%d)", fieldType, size, precision)
+	}
+	return fmt.Sprintf("%c(%d)", fieldType, size)
+}
+
+// hsSchema is the declared structure of HS.shp (hišne številke), see:
+// https://www.e-prostor.gov.si/fileadmin/struktura/EGP/RPE_struktura.docx
+var hsSchema = []rpeField{
+	{name: "ENOTA", fieldType: 'C', size: 2},                           // Šifra enote
+	{name: "HS_MID", fieldType: 'N', size: 8},                          // Identifikator hišne številke
+	{name: "HS", fieldType: 'N', size: 3},                              // Hišna številka
+	{name: "HD", fieldType: 'C', size: 1},                              // Dodatek k hišni številki
+	{name: "LABELA", fieldType: 'C', size: 4},                          // Hišna številka z dodatkom – združen zapis polj HS in HD
+	{name: "UL_MID", fieldType: 'N', size: 8},                          // Identifikator ulice
+	{name: "NA_MID", fieldType: 'N', size: 8},                          // Identifikator naselja
+	{name: "OB_MID", fieldType: 'N', size: 8},                          // Identifikator občine
+	{name: "PT_MID", fieldType: 'N', size: 8},                          // Identifikator poštnega okoliša
+	{name: "PO_MID", fieldType: 'N', size: 8},                          // Identifikator prostorskega okoliša
+	{name: "D_OD", fieldType: 'D', size: 8},                            // Datum veljavnosti
+	{name: "DV_OD", fieldType: 'D', size: 8},                           // Datum vnosa v bazo
+	{name: "STATUS", fieldType: 'C', size: 1},                          // Status veljavnosti zapisa (V – veljavno stanje)
+	{name: "CEN_E", fieldType: 'N', size: 6, aliases: []string{"Y_C"}}, // E (D96/TM) ali Y (D48/GK) koordinata centroida hišne številke
+	{name: "CEN_N", fieldType: 'N', size: 6, aliases: []string{"X_C"}}, // N (D96/TM) ali X (D48/GK) koordinata centroida hišne številke
+}
+
+// hsColumns holds the DBF column indices of all HS fields we use, resolved by name
+type hsColumns struct {
+	enota, hsMid, hs, hd, labela, ulMid, naMid, obMid, ptMid, poMid, dOd, dvOd, status int
+}
+
+// resolveHsColumns validates fields against hsSchema and returns the column indices
+func resolveHsColumns(fields []shp.Field) (*hsColumns, error) {
+	indices, err := resolveSchema(fields, hsSchema)
+	if err != nil {
+		return nil, fmt.Errorf("HS schema mismatch:\n%w", err)
+	}
+
+	return &hsColumns{
+		enota:  indices["ENOTA"],
+		hsMid:  indices["HS_MID"],
+		hs:     indices["HS"],
+		hd:     indices["HD"],
+		labela: indices["LABELA"],
+		ulMid:  indices["UL_MID"],
+		naMid:  indices["NA_MID"],
+		obMid:  indices["OB_MID"],
+		ptMid:  indices["PT_MID"],
+		poMid:  indices["PO_MID"],
+		dOd:    indices["D_OD"],
+		dvOd:   indices["DV_OD"],
+		status: indices["STATUS"],
+	}, nil
+}
+
+// schemaError lists all differences between the declared and the actual DBF structure
+type schemaError []string
+
+func (e schemaError) Error() string {
+	return strings.Join(e, "\n")
+}
+
+// resolveSchema looks up every declared field by name (or alias) and checks its type and size.
+// It returns the column index for each declared name, or a schemaError with a readable diff.
+func resolveSchema(fields []shp.Field, schema []rpeField) (map[string]int, error) {
+	indices := make(map[string]int, len(schema))
+	used := make(map[int]bool, len(fields))
+	var diff schemaError
+	var missing []rpeField
+
+	for _, declared := range schema {
+		index := getColumnIndex(fields, declared.name)
+		for _, alias := range declared.aliases {
+			if index >= 0 {
+				break
+			}
+			index = getColumnIndex(fields, alias)
+		}
+		if index < 0 {
+			missing = append(missing, declared)
+			continue
+		}
+
+		used[index] = true
+		indices[declared.name] = index
+
+		actual := fields[index]
+		if actual.Fieldtype != declared.fieldType || actual.Size != declared.size || actual.Precision != declared.precision {
+			diff = append(diff, fmt.Sprintf("  ~ %s: expected %s, found %s", declared.name,
+				formatFieldType(declared.fieldType, declared.size, declared.precision),
+				formatFieldType(actual.Fieldtype, actual.Size, actual.Precision)))
+		}
+	}
+
+	var unexpected []int
+	for i := range fields {
+		if !used[i] {
+			unexpected = append(unexpected, i)
+		}
+	}
+
+	for _, declared := range missing {
+		// a missing column with an unexpected one of the same type in its place was most likely renamed
+		renamed := -1
+		for _, i := range unexpected {
+			if fields[i].Fieldtype == declared.fieldType && fields[i].Size == declared.size {
+				renamed = i
+				break
+			}
+		}
+		if renamed >= 0 {
+			diff = append(diff, fmt.Sprintf("  ~ %s: renamed to %s?", declared, fields[renamed].String()))
+			unexpected = removeInt(unexpected, renamed)
+		} else {
+			diff = append(diff, fmt.Sprintf("  - %s: missing", declared))
+		}
+	}
+
+	if len(diff) == 0 {
+		// extra columns alone are harmless
+		return indices, nil
+	}
+
+	for _, i := range unexpected {
+		diff = append(diff, fmt.Sprintf("  + %s %s: not declared (column %d)", fields[i].String(),
+			formatFieldType(fields[i].Fieldtype, fields[i].Size, fields[i].Precision), i))
+	}
+
+	return nil, diff
+}
+
+func removeInt(values []int, value int) []int {
+	for i, v := range values {
+		if v == value {
+			return append(values[:i], values[i+1:]...)
+		}
+	}
+	return values
+}
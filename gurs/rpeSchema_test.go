@@ -0,0 +1,79 @@
+package gurs
+
+import (
+	"strings"
+	"testing"
+
+	shp "github.com/jonas-p/go-shp"
+)
+
+// schemaFields builds DBF fields exactly as declared in the given schema
+func schemaFields(schema []rpeField) []shp.Field {
+	fields := make([]shp.Field, 0, len(schema))
+	for _, declared := range schema {
+		field := shp.Field{Fieldtype: declared.fieldType, Size: declared.size, Precision: declared.precision}
+		copy(field.Name[:], declared.name)
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func TestResolveHsColumns(t *testing.T) {
+	columns, err := resolveHsColumns(schemaFields(hsSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, columns.hsMid, 1)
+	assertEqual(t, columns.labela, 4)
+	assertEqual(t, columns.ulMid, 5)
+	assertEqual(t, columns.ptMid, 8)
+	assertEqual(t, columns.status, 12)
+}
+
+func TestResolveHsColumnsReordered(t *testing.T) {
+	fields := schemaFields(hsSchema)
+	// swap UL_MID and PT_MID, plus an extra column at the end
+	fields[5], fields[8] = fields[8], fields[5]
+	fields = append(fields, shp.StringField("EXTRA", 10))
+
+	columns, err := resolveHsColumns(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, columns.ulMid, 8)
+	assertEqual(t, columns.ptMid, 5)
+}
+
+func TestResolveHsColumnsAliases(t *testing.T) {
+	fields := schemaFields(hsSchema)
+	copy(fields[13].Name[:], "Y_C\x00\x00")
+	copy(fields[14].Name[:], "X_C\x00\x00")
+
+	if _, err := resolveHsColumns(fields); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveHsColumnsMismatch(t *testing.T) {
+	fields := schemaFields(hsSchema)
+	fields[4].Size = 6                                  // LABELA retyped
+	fields[8] = shp.NumberField("POSTA_MID", 8)         // PT_MID renamed
+	fields = append(fields[:12], fields[13:]...)        // STATUS missing
+	fields = append(fields, shp.StringField("NOTE", 9)) // unexpected
+
+	_, err := resolveHsColumns(fields)
+	if err == nil {
+		t.Fatal("expected schema mismatch")
+	}
+
+	for _, expected := range []string{
+		"~ LABELA: expected C(4), found C(6)",
+		"~ PT_MID N(8.0): renamed to POSTA_MID?",
+		"- STATUS C(1): missing",
+		"+ NOTE C(9): not declared",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("%q not found in:\n%s", expected, err)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package gurs
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	shp "github.com/jonas-p/go-shp"
+)
+
+// ShapeSource opens RPE shapefiles (HS, PT, UL, NA, OB...) by name
+type ShapeSource interface {
+	Open(name string) (*Shapefile, error)
+	Close() error
+}
+
+// Shapefile is an opened shapefile together with its projection (.prj content) and modification time
+type Shapefile struct {
+	shp.SequentialReader
+	Name     string
+	Prj      string
+	Modified time.Time
+}
+
+// OpenSource returns an archiveSource if dir contains downloaded RPE_*.ZIP archives,
+// otherwise a directorySource with already extracted shapefiles
+func OpenSource(dir string) (ShapeSource, error) {
+	archives, err := filepath.Glob(filepath.Join(dir, "RPE_*.ZIP"))
+	if err != nil {
+		return nil, err
+	}
+	if len(archives) == 0 {
+		return &directorySource{dir: dir}, nil
+	}
+	return openArchives(archives)
+}
+
+// directorySource opens extracted shapefiles from <dir>/<NAME>/<NAME>.shp
+type directorySource struct {
+	dir string
+}
+
+func (s *directorySource) Open(name string) (*Shapefile, error) {
+	return OpenShapefile(filepath.Join(s.dir, name, name+".shp"))
+}
+
+func (s *directorySource) Close() error {
+	return nil
+}
+
+// OpenShapefile opens the shapefile with the given filename from disk
+func OpenShapefile(filename string) (*Shapefile, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := shp.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	prj, err := os.ReadFile(strings.TrimSuffix(filename, filepath.Ext(filename)) + ".prj")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		reader.Close()
+		return nil, err
+	}
+
+	return &Shapefile{SequentialReader: reader, Name: filename, Prj: string(prj), Modified: info.ModTime()}, nil
+}
+
+// archiveSource reads shapefiles directly from the downloaded RPE_*.ZIP archives,
+// which contain one more zip per layer (eg. RPE_PE.ZIP -> PT.zip -> PT/PT.shp)
+type archiveSource struct {
+	filenames []string
+	archives  []*zip.ReadCloser
+	// nested zips are spilled to temporary files as zip needs random access
+	tempFiles []*os.File
+	// shapefile members by layer name and extension, eg. members["PT"][".dbf"]
+	members map[string]map[string]archiveMember
+}
+
+type archiveMember struct {
+	file *zip.File
+	// human readable path, eg. RPE_PE.ZIP/PT.zip/PT/PT.shp
+	path string
+}
+
+func openArchives(filenames []string) (*archiveSource, error) {
+	s := &archiveSource{filenames: filenames, members: make(map[string]map[string]archiveMember)}
+	for _, filename := range filenames {
+		archive, err := zip.OpenReader(filename)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.archives = append(s.archives, archive)
+
+		if err := s.index(&archive.Reader, filepath.Base(filename)); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// index records all shapefile members of the archive, descending into nested zips
+func (s *archiveSource) index(archive *zip.Reader, archivePath string) error {
+	for _, file := range archive.File {
+		memberPath := archivePath + "/" + file.Name
+		ext := strings.ToLower(path.Ext(file.Name))
+
+		switch ext {
+		case ".zip":
+			nested, err := s.openNested(file)
+			if err != nil {
+				return fmt.Errorf("%s: %w", memberPath, err)
+			}
+			if err := s.index(nested, memberPath); err != nil {
+				return err
+			}
+		case ".shp", ".dbf", ".prj":
+			name := strings.ToUpper(strings.TrimSuffix(path.Base(file.Name), path.Ext(file.Name)))
+			if s.members[name] == nil {
+				s.members[name] = make(map[string]archiveMember)
+			}
+			s.members[name][ext] = archiveMember{file: file, path: memberPath}
+		}
+	}
+	return nil
+}
+
+func (s *archiveSource) openNested(file *zip.File) (*zip.Reader, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	temp, err := os.CreateTemp("", "rpe-*.zip")
+	if err != nil {
+		return nil, err
+	}
+	s.tempFiles = append(s.tempFiles, temp)
+
+	size, err := io.Copy(temp, rc)
+	if err != nil {
+		return nil, err
+	}
+	return zip.NewReader(temp, size)
+}
+
+func (s *archiveSource) Open(name string) (*Shapefile, error) {
+	members := s.members[strings.ToUpper(name)]
+	shpMember, foundShp := members[".shp"]
+	dbfMember, foundDbf := members[".dbf"]
+	if !foundShp || !foundDbf {
+		return nil, fmt.Errorf("%s.shp/.dbf not found in archives: %w", name, fs.ErrNotExist)
+	}
+
+	shpReader, err := shpMember.file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", shpMember.path, err)
+	}
+	dbfReader, err := dbfMember.file.Open()
+	if err != nil {
+		shpReader.Close()
+		return nil, fmt.Errorf("%s: %w", dbfMember.path, err)
+	}
+
+	var prj string
+	if prjMember, found := members[".prj"]; found {
+		if prj, err = readMember(prjMember.file); err != nil {
+			shpReader.Close()
+			dbfReader.Close()
+			return nil, fmt.Errorf("%s: %w", prjMember.path, err)
+		}
+	}
+
+	return &Shapefile{
+		SequentialReader: shp.SequentialReaderFromExt(shpReader, dbfReader),
+		Name:             shpMember.path,
+		Prj:              prj,
+		Modified:         shpMember.file.Modified,
+	}, nil
+}
+
+func readMember(file *zip.File) (string, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(rc)
+	return string(content), err
+}
+
+func (s *archiveSource) Close() error {
+	var errs []error
+	for _, archive := range s.archives {
+		errs = append(errs, archive.Close())
+	}
+	for _, temp := range s.tempFiles {
+		errs = append(errs, temp.Close(), os.Remove(temp.Name()))
+	}
+	return errors.Join(errs...)
+}
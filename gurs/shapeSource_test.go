@@ -0,0 +1,169 @@
+package gurs
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	shp "github.com/jonas-p/go-shp"
+)
+
+// writeTestShapefile writes a point shapefile <dir>/<name>/<name>.shp with the given attribute rows
+func writeTestShapefile(t testing.TB, dir, name string, fields []shp.Field, points []shp.Point, rows [][]string) string {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(dir, name, name+".shp")
+	writer, err := shp.Create(filename, shp.POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.SetFields(fields); err != nil {
+		t.Fatal(err)
+	}
+	for i, row := range rows {
+		point := points[i]
+		writer.Write(&point)
+		for j, value := range row {
+			// pad values like real DBF files do, numbers to the right
+			if fields[j].Fieldtype == 'N' {
+				value = fmt.Sprintf("%*s", fields[j].Size, value)
+			} else {
+				value = fmt.Sprintf("%-*s", fields[j].Size, value)
+			}
+			if err := writer.WriteAttribute(i, j, value); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	writer.Close()
+
+	// go-shp writer names the DBF <name>dbf, without the dot
+	base := filepath.Join(dir, name, name)
+	if err := os.Rename(base+"dbf", base+".dbf"); err != nil {
+		t.Fatal(err)
+	}
+
+	return filename
+}
+
+// zipFiles returns a zip with the given members, all modified at the given time
+func zipFiles(t testing.TB, modified time.Time, members map[string][]byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range members {
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func writeTestPT(t testing.TB, dir string) {
+	writeTestShapefile(t, dir, "PT",
+		[]shp.Field{shp.NumberField("PT_MID", 8), shp.NumberField("PT_ID", 4), shp.StringField("PT_UIME", 40)},
+		[]shp.Point{{X: 462000, Y: 101000}, {X: 399000, Y: 46000}},
+		[][]string{{"11026975", "1000", "Ljubljana"}, {"11027123", "6330", EncodeWindows1250("Piran - Pirano")}},
+	)
+}
+
+func TestArchiveSource(t *testing.T) {
+	extracted := t.TempDir()
+	writeTestPT(t, extracted)
+
+	members := make(map[string][]byte)
+	for _, ext := range []string{".shp", ".shx", ".dbf"} {
+		content, err := os.ReadFile(filepath.Join(extracted, "PT", "PT"+ext))
+		if err != nil {
+			t.Fatal(err)
+		}
+		members["PT/PT"+ext] = content
+	}
+	members["PT/PT.prj"] = []byte(`PROJCS["D96_TM",GEOGCS["GCS_D96"],PROJECTION["Transverse_Mercator"]]`)
+
+	modified := time.Date(2024, 3, 17, 10, 0, 0, 0, time.UTC)
+	inner := zipFiles(t, modified, members)
+	outer := zipFiles(t, modified.Add(time.Hour), map[string][]byte{"RPE_PE/PT.zip": inner})
+
+	downloaded := t.TempDir()
+	if err := os.WriteFile(filepath.Join(downloaded, "RPE_PE.ZIP"), outer, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := OpenSource(downloaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	if _, isArchive := source.(*archiveSource); !isArchive {
+		t.Fatalf("expected archiveSource, got %T", source)
+	}
+
+	pt, err := source.Open("PT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pt.Close()
+
+	assertEqual(t, pt.Name, "RPE_PE.ZIP/RPE_PE/PT.zip/PT/PT.shp")
+	assertEqual(t, pt.Modified.UTC().Format(time.RFC3339), modified.Format(time.RFC3339))
+	if _, err := coordinateTransformation(pt.Prj); err != nil {
+		t.Error(err)
+	}
+
+	var names []string
+	for pt.Next() {
+		names = append(names, DecodeWindows1250(pt.Attribute(2)))
+	}
+	if pt.Err() != nil {
+		t.Fatal(pt.Err())
+	}
+	assertEqual(t, len(names), 2)
+	assertEqual(t, names[1], "Piran - Pirano")
+
+	if _, err := source.Open("HS"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected not exist error, got %v", err)
+	}
+}
+
+func TestDirectorySource(t *testing.T) {
+	extracted := t.TempDir()
+	writeTestPT(t, extracted)
+
+	source, err := OpenSource(extracted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	pt, err := source.Open("PT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pt.Close()
+
+	count := 0
+	for pt.Next() {
+		count++
+	}
+	assertEqual(t, count, 2)
+	assertEqual(t, pt.Prj, "")
+}
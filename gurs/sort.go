@@ -0,0 +1,193 @@
+package gurs
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	geojson "github.com/paulmach/go.geojson"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// SortOrder selects the keys SortFeatureCollection orders the addresses by
+type SortOrder string
+
+const (
+	// SortByPostcode orders by the post code, street (or place) and house number
+	SortByPostcode SortOrder = "postcode"
+	// SortBySettlement orders by the settlement, street (or place) and house number
+	SortBySettlement SortOrder = "settlement"
+	// SortSpatial orders along a Hilbert curve (see spatialIndex) so neighbouring addresses are close in the file,
+	// addresses at the same place by street and house number
+	SortSpatial SortOrder = "spatial"
+)
+
+// DefaultSortOrder is the order of the published files
+const DefaultSortOrder = SortByPostcode
+
+// ParseSortOrder returns the order named s
+func ParseSortOrder(s string) (SortOrder, error) {
+	switch order := SortOrder(strings.TrimSpace(s)); order {
+	case SortByPostcode, SortBySettlement, SortSpatial:
+		return order, nil
+	}
+	return "", fmt.Errorf("unknown sort order %q, should be %s, %s or %s", s, SortByPostcode, SortBySettlement, SortSpatial)
+}
+
+// sortKey of a feature, names are Slovenian collation keys
+type sortKey struct {
+	feature *geojson.Feature
+	// post code or settlement, nil for SortSpatial
+	area, street []byte
+	// house number without leading zeros and the collation key of its suffix
+	number string
+	suffix []byte
+	// position on the Hilbert curve, 0 unless SortSpatial
+	spatial uint64
+	ref     string
+}
+
+// SortFeatureCollection sorts the Features of the given FeatureCollection for reproducible results and better compression,
+// by the keys of the profile in the order (DefaultSortOrder if empty); names are compared with Slovenian collation
+// (č after c, numbers in names by value), house numbers naturally (see CompareHouseNumbers) and equal addresses by the ref
+func SortFeatureCollection(featureCollection geojson.FeatureCollection, profile *TaggingProfile, order SortOrder) {
+	features := featureCollection.Features
+	keys := newSortKeys(features, profile.Keys, order)
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].compare(&keys[j]) < 0
+	})
+	for i := range keys {
+		features[i] = keys[i].feature
+	}
+}
+
+// newSortKeys computes the keys of all features once, the collator is not safe for concurrent use
+func newSortKeys(features []*geojson.Feature, tagKeys TagKeys, order SortOrder) []sortKey {
+	collator := collate.New(language.Slovenian, collate.Numeric)
+	buffer := &collate.Buffer{}
+	collationKey := func(s string) []byte {
+		return collator.KeyFromString(buffer, s)
+	}
+
+	keys := make([]sortKey, len(features))
+	for i, f := range features {
+		number, suffix := splitHouseNumber(stringProperty(f, tagKeys.Housenumber))
+		keys[i] = sortKey{
+			feature: f,
+			street:  collationKey(stringProperty(f, streetOrPlace(f, tagKeys))),
+			number:  number,
+			suffix:  collationKey(suffix),
+			ref:     stringProperty(f, tagKeys.Ref),
+		}
+		switch order {
+		case SortSpatial:
+			if f.Geometry != nil && len(f.Geometry.Point) >= 2 {
+				keys[i].spatial = spatialIndex(f.Geometry.Point[0], f.Geometry.Point[1])
+			}
+		case SortBySettlement:
+			keys[i].area = collationKey(settlement(f, tagKeys))
+		default:
+			keys[i].area = []byte(stringProperty(f, tagKeys.Postcode))
+		}
+	}
+	return keys
+}
+
+// compare returns -1, 0 or +1, only features with the same ref are equal
+func (a *sortKey) compare(b *sortKey) int {
+	if c := cmp.Compare(a.spatial, b.spatial); c != 0 {
+		return c
+	}
+	if c := bytes.Compare(a.area, b.area); c != 0 {
+		return c
+	}
+	if c := bytes.Compare(a.street, b.street); c != 0 {
+		return c
+	}
+	if c := compareNumbers(a.number, b.number); c != 0 {
+		return c
+	}
+	if c := bytes.Compare(a.suffix, b.suffix); c != 0 {
+		return c
+	}
+	return compareNumbers(a.ref, b.ref)
+}
+
+// stringProperty returns the value of the key, empty if missing
+func stringProperty(f *geojson.Feature, key string) string {
+	value, _ := f.Properties[key].(string)
+	return value
+}
+
+// streetOrPlace returns the street key, or the place key of addresses without a street
+func streetOrPlace(f *geojson.Feature, keys TagKeys) string {
+	if _, found := f.Properties[keys.Street]; found {
+		return keys.Street
+	}
+	return keys.Place
+}
+
+// settlement returns addr:village, addr:place or the post name, the converter leaves the village out
+// where it is already in the street or post name
+func settlement(f *geojson.Feature, keys TagKeys) string {
+	for _, key := range []string{keys.Village, keys.Place} {
+		if value := stringProperty(f, key); key != "" && value != "" {
+			return value
+		}
+	}
+	return stringProperty(f, keys.City)
+}
+
+// splitHouseNumber returns the leading number without leading zeros and the rest, eg. "012ab" -> "12", "ab"
+func splitHouseNumber(housenumber string) (number, suffix string) {
+	digits := strings.IndexFunc(housenumber, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if digits < 0 {
+		digits = len(housenumber)
+	}
+	return strings.TrimLeft(housenumber[:digits], "0"), housenumber[digits:]
+}
+
+// compareNumbers compares non-negative integers of any length written without leading zeros
+func compareNumbers(a, b string) int {
+	if len(a) != len(b) {
+		return cmp.Compare(len(a), len(b))
+	}
+	return strings.Compare(a, b)
+}
+
+// CompareHouseNumbers compares house numbers naturally, by the number and then by the suffix,
+// eg. 9 < 10 < 10a < 10ab < 10b < 1000; returns -1, 0 or +1.
+// SortFeatureCollection compares the suffixes with Slovenian collation, here they are compared as written
+func CompareHouseNumbers(a, b string) int {
+	numberA, suffixA := splitHouseNumber(a)
+	numberB, suffixB := splitHouseNumber(b)
+	if c := compareNumbers(numberA, numberB); c != 0 {
+		return c
+	}
+	return strings.Compare(suffixA, suffixB)
+}
+
+// NormalizeHouseNumber returns comparable house number (4 digits, followed by one letter or _)
+//
+// Deprecated: the result only compares correctly up to 3 digits and 1 letter, use CompareHouseNumbers.
+func NormalizeHouseNumber(housenumber string) string {
+
+	if lastRune, n := utf8.DecodeLastRuneInString(housenumber); n == 0 || unicode.IsDigit(lastRune) {
+		// pad right side with _ if it ends with digit to accommodate for 1-letter suffixes
+		// pad left with zeros to get to 3-digits
+		// eg "12" -> "012_" (4 characters)
+		return fmt.Sprintf("%03s_", housenumber)
+	}
+
+	// there is already a letter at the end,
+	// just pad left side with zeros to keep all lengths equal to 4 characters
+	// eg "12c" -> "012c" (4 characters)
+	return fmt.Sprintf("%04s", housenumber)
+}
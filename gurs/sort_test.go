@@ -0,0 +1,112 @@
+package gurs
+
+import (
+	"strings"
+	"testing"
+
+	geojson "github.com/paulmach/go.geojson"
+)
+
+func TestNormalizeHouseNumbers(t *testing.T) {
+	//assertEqual(t, NormalizeHouseNumber(""), "000_")
+	assertEqual(t, NormalizeHouseNumber("a"), "000a")
+	assertEqual(t, NormalizeHouseNumber("1"), "001_")
+	assertEqual(t, NormalizeHouseNumber("12"), "012_")
+	assertEqual(t, NormalizeHouseNumber("2b"), "002b")
+	assertEqual(t, NormalizeHouseNumber("123c"), "123c")
+	assertEqual(t, NormalizeHouseNumber("123ž"), "123ž")
+}
+
+func BenchmarkNormalizeHouseNumbersWithoutLetter(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		NormalizeHouseNumber("12")
+	}
+}
+func BenchmarkNormalizeHouseNumbersWithLetter(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		NormalizeHouseNumber("123ž")
+	}
+}
+
+func TestCompareHouseNumbers(t *testing.T) {
+	ordered := []string{"", "a", "1", "2", "2a", "2ab", "2b", "9", "010", "10a", "99", "1000", "1000a", "12345678901234567890"}
+	for i := range ordered {
+		for j := range ordered {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got := CompareHouseNumbers(ordered[i], ordered[j]); got != want {
+				t.Errorf("CompareHouseNumbers(%q, %q) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+	assertEqual(t, CompareHouseNumbers("07", "7"), 0)
+}
+
+func TestParseSortOrder(t *testing.T) {
+	order, err := ParseSortOrder(" settlement")
+	assertEqual(t, order, SortBySettlement)
+	assertEqual(t, err, nil)
+	_, err = ParseSortOrder("street")
+	assertEqual(t, err.Error(), `unknown sort order "street", should be postcode, settlement or spatial`)
+}
+
+func testSortFeature(ref, postcode, village, street, housenumber string, lon, lat float64) *geojson.Feature {
+	f := geojson.NewPointFeature([]float64{lon, lat})
+	f.SetProperty(tagRef, ref)
+	f.SetProperty(tagPostCode, postcode)
+	f.SetProperty(tagCity, "Ljubljana")
+	f.SetProperty(tagHousenumber, housenumber)
+	if village != "" {
+		f.SetProperty(tagVillage, village)
+	}
+	if street != "" {
+		f.SetProperty(tagStreet, street)
+	} else {
+		f.SetProperty(tagPlace, village)
+	}
+	return f
+}
+
+func sortedRefs(featureCollection *geojson.FeatureCollection, order SortOrder) string {
+	SortFeatureCollection(*featureCollection, DefaultTaggingProfile(), order)
+	var refs []string
+	for _, f := range featureCollection.Features {
+		refs = append(refs, f.Properties[tagRef].(string))
+	}
+	return strings.Join(refs, ",")
+}
+
+func TestSortFeatureCollection(t *testing.T) {
+	features := []*geojson.Feature{
+		testSortFeature("1", "1000", "", "Zaloška cesta", "2", 14.6, 46.05),
+		testSortFeature("2", "1000", "", "Čopova ulica", "10", 14.5, 46.05),
+		testSortFeature("3", "1000", "", "Čopova ulica", "9", 14.5, 46.06),
+		testSortFeature("4", "1000", "", "Cesta 14. divizije", "1000", 14.4, 46),
+		testSortFeature("5", "1000", "", "Cesta 4. maja", "10a", 14.4, 46),
+		testSortFeature("6", "1000", "", "Dalmatinova ulica", "1", 14.5, 46.1),
+		testSortFeature("7", "1210", "Šentvid", "Cesta 4. maja", "1", 14.4, 46.1),
+		testSortFeature("8", "1000", "Črnuče", "", "5", 14.5, 46.1),
+		testSortFeature("9", "1000", "", "Čopova ulica", "09", 14.5, 46.06),
+	}
+
+	// the input order does not matter
+	for _, reversed := range []bool{false, true} {
+		featureCollection := geojson.NewFeatureCollection()
+		for i := range features {
+			if reversed {
+				featureCollection.AddFeature(features[len(features)-1-i])
+			} else {
+				featureCollection.AddFeature(features[i])
+			}
+		}
+		assertEqual(t, sortedRefs(featureCollection, DefaultSortOrder), "5,4,3,9,2,8,6,1,7")
+		assertEqual(t, sortedRefs(featureCollection, SortBySettlement), "8,5,4,3,9,2,6,1,7")
+		assertEqual(t, sortedRefs(featureCollection, SortSpatial), "7,5,4,1,8,6,3,9,2")
+	}
+}
@@ -0,0 +1,133 @@
+package gurs
+
+import (
+	"math"
+	"sort"
+)
+
+// length of one degree of latitude in meters
+const metersPerDegree = earthRadius * math.Pi / 180
+
+// gridIndex buckets points into cells of at least cellSize meters for radius queries
+type gridIndex struct {
+	// cell size in degrees
+	cellLon, cellLat float64
+	cells            map[[2]int][]int
+	lons, lats       []float64
+}
+
+// gridCells returns the size in degrees of cells at least cellSize meters wide and high
+func gridCells(cellSize float64) (float64, float64) {
+	// degrees of longitude are shortest at the north of Slovenia, cells are at least cellSize wide everywhere
+	return cellSize / (metersPerDegree * math.Cos(degreesToRadians(maxLat))), cellSize / metersPerDegree
+}
+
+func newGridIndex(cellSize float64) *gridIndex {
+	cellLon, cellLat := gridCells(cellSize)
+	return &gridIndex{cellLon: cellLon, cellLat: cellLat, cells: make(map[[2]int][]int)}
+}
+
+func (g *gridIndex) cell(lon, lat float64) [2]int {
+	return [2]int{int(math.Floor(lon / g.cellLon)), int(math.Floor(lat / g.cellLat))}
+}
+
+// insert adds the point and returns its id, ids are consecutive from 0
+func (g *gridIndex) insert(lon, lat float64) int {
+	id := len(g.lons)
+	g.lons = append(g.lons, lon)
+	g.lats = append(g.lats, lat)
+	cell := g.cell(lon, lat)
+	g.cells[cell] = append(g.cells[cell], id)
+	return id
+}
+
+// within returns ids of points at most radius meters away, sorted by id
+func (g *gridIndex) within(lon, lat, radius float64) []int {
+	// cells needed to cover the radius in each direction
+	reach := int(math.Ceil(radius/(g.cellLat*metersPerDegree))) + 1
+	center := g.cell(lon, lat)
+
+	var ids []int
+	for x := center[0] - reach; x <= center[0]+reach; x++ {
+		for y := center[1] - reach; y <= center[1]+reach; y++ {
+			for _, id := range g.cells[[2]int{x, y}] {
+				if Distance(lon, lat, g.lons[id], g.lats[id]) <= radius {
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// inBox returns ids of points inside the bounding box, sorted by id
+func (g *gridIndex) inBox(minLon, minLat, maxLon, maxLat float64) []int {
+	minCell, maxCell := g.cell(minLon, minLat), g.cell(maxLon, maxLat)
+
+	var ids []int
+	add := func(cellIds []int) {
+		for _, id := range cellIds {
+			if g.lons[id] >= minLon && g.lons[id] <= maxLon && g.lats[id] >= minLat && g.lats[id] <= maxLat {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if (maxCell[0]-minCell[0]+1)*(maxCell[1]-minCell[1]+1) > len(g.cells) {
+		// large box, cheaper to check all occupied cells
+		for _, cellIds := range g.cells {
+			add(cellIds)
+		}
+	} else {
+		for x := minCell[0]; x <= maxCell[0]; x++ {
+			for y := minCell[1]; y <= maxCell[1]; y++ {
+				add(g.cells[[2]int{x, y}])
+			}
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// boxIndex buckets bounding boxes into all cells they overlap, for point queries
+type boxIndex struct {
+	cellLon, cellLat float64
+	cells            map[[2]int][]int
+	// minLon, minLat, maxLon, maxLat
+	boxes [][4]float64
+}
+
+func newBoxIndex(cellSize float64) *boxIndex {
+	cellLon, cellLat := gridCells(cellSize)
+	return &boxIndex{cellLon: cellLon, cellLat: cellLat, cells: make(map[[2]int][]int)}
+}
+
+func (b *boxIndex) cell(lon, lat float64) [2]int {
+	return [2]int{int(math.Floor(lon / b.cellLon)), int(math.Floor(lat / b.cellLat))}
+}
+
+// insert adds the box and returns its id, ids are consecutive from 0
+func (b *boxIndex) insert(minLon, minLat, maxLon, maxLat float64) int {
+	id := len(b.boxes)
+	b.boxes = append(b.boxes, [4]float64{minLon, minLat, maxLon, maxLat})
+	minCell, maxCell := b.cell(minLon, minLat), b.cell(maxLon, maxLat)
+	for x := minCell[0]; x <= maxCell[0]; x++ {
+		for y := minCell[1]; y <= maxCell[1]; y++ {
+			b.cells[[2]int{x, y}] = append(b.cells[[2]int{x, y}], id)
+		}
+	}
+	return id
+}
+
+// containing returns ids of boxes containing the point, sorted by id
+func (b *boxIndex) containing(lon, lat float64) []int {
+	var ids []int
+	for _, id := range b.cells[b.cell(lon, lat)] {
+		box := b.boxes[id]
+		if lon >= box[0] && lon <= box[2] && lat >= box[1] && lat <= box[3] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
@@ -0,0 +1,23 @@
+package gurs
+
+import "testing"
+
+func TestGridIndex(t *testing.T) {
+	index := newGridIndex(20)
+	index.insert(14.5, 46.05)
+	// about 15 m east
+	index.insert(14.5002, 46.05)
+	// about 22 m north
+	index.insert(14.5, 46.0502)
+	index.insert(15.5, 46.5)
+
+	assertEqual(t, len(index.within(14.5, 46.05, 20)), 2)
+	assertEqual(t, len(index.within(14.5, 46.05, 25)), 3)
+	assertEqual(t, len(index.within(14.5, 46.05, 0)), 1)
+	assertEqual(t, len(index.within(14.4, 46.05, 20)), 0)
+
+	inBox := index.inBox(14.4, 46, 14.6, 46.1)
+	assertEqual(t, len(inBox), 3)
+	assertEqual(t, inBox[2], 2)
+	assertEqual(t, len(index.inBox(13, 45, 17, 47.5)), 4)
+}
@@ -0,0 +1,151 @@
+package gurs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	geojson "github.com/paulmach/go.geojson"
+)
+
+// encoded features kept in memory before they are appended to the spill files
+const defaultSpillBufferSize = 16 << 20
+
+// Spill is a FeatureSink bucketing features to one file per category in a temporary directory,
+// so only the buffer and one category at a time (see ForEach) are kept in memory
+type Spill struct {
+	dir string
+	// spill file name by category
+	files map[string]string
+	// features not yet written, newline delimited GeoJSON by category
+	buffers     map[string]*bytes.Buffer
+	buffered    int
+	maxBuffered int
+	count       int
+}
+
+// NewSpill creates the spill directory in tempDir (system default if empty), remove it with Close
+func NewSpill(tempDir string) (*Spill, error) {
+	dir, err := os.MkdirTemp(tempDir, "gurs-spill-")
+	if err != nil {
+		return nil, err
+	}
+	return &Spill{
+		dir:         dir,
+		files:       make(map[string]string),
+		buffers:     make(map[string]*bytes.Buffer),
+		maxBuffered: defaultSpillBufferSize,
+	}, nil
+}
+
+// Add buffers the encoded feature, flushing all buffers when they grow too big
+func (s *Spill) Add(category string, f *geojson.Feature) error {
+	rawJSON, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := s.files[category]; !ok {
+		// categories contain "/" and non-ASCII letters, number the files instead
+		s.files[category] = filepath.Join(s.dir, fmt.Sprintf("%05d.ndjson", len(s.files)))
+	}
+	buffer, ok := s.buffers[category]
+	if !ok {
+		buffer = &bytes.Buffer{}
+		s.buffers[category] = buffer
+	}
+	buffer.Write(rawJSON)
+	buffer.WriteByte('\n')
+	s.buffered += len(rawJSON) + 1
+	s.count++
+
+	if s.buffered > s.maxBuffered {
+		return s.flush()
+	}
+	return nil
+}
+
+// flush appends all buffers to their spill files and drops them
+func (s *Spill) flush() error {
+	for category, buffer := range s.buffers {
+		file, err := os.OpenFile(s.files[category], os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		if _, err := buffer.WriteTo(file); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	s.buffers = make(map[string]*bytes.Buffer)
+	s.buffered = 0
+	return nil
+}
+
+// Count returns the number of features added
+func (s *Spill) Count() int {
+	return s.count
+}
+
+// Categories returns all categories with at least one feature, sorted alphabetically
+func (s *Spill) Categories() []string {
+	categories := make([]string, 0, len(s.files))
+	for category := range s.files {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// ForEach reads back the categories in alphabetical order and calls fn with all features of each one
+func (s *Spill) ForEach(fn func(category string, featureCollection *geojson.FeatureCollection) error) error {
+	if err := s.flush(); err != nil {
+		return err
+	}
+
+	for _, category := range s.Categories() {
+		featureCollection, err := s.read(category)
+		if err != nil {
+			return err
+		}
+		if err := fn(category, featureCollection); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Spill) read(category string) (*geojson.FeatureCollection, error) {
+	file, err := os.Open(s.files[category])
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	featureCollection := geojson.NewFeatureCollection()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		f, err := geojson.UnmarshalFeature(scanner.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("spilled %s: %w", category, err)
+		}
+		featureCollection.AddFeature(f)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("spilled %s: %w", category, err)
+	}
+	return featureCollection, nil
+}
+
+// Close removes the spill directory
+func (s *Spill) Close() error {
+	return os.RemoveAll(s.dir)
+}
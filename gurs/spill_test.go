@@ -0,0 +1,117 @@
+package gurs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+
+	geojson "github.com/paulmach/go.geojson"
+)
+
+func newTestSpill(t *testing.T) *Spill {
+	t.Helper()
+
+	spill, err := NewSpill(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { spill.Close() })
+	return spill
+}
+
+func TestSpillRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	writeTestData(t, dir, testRecords)
+	converter := newTestConverter(t, dir)
+	spill := newTestSpill(t)
+	if err := converter.Convert(openTestHS(t, converter), spill); err != nil {
+		t.Fatal(err)
+	}
+	_, want, _ := convertTestData(t, testRecords)
+	// 1005 is not valid
+	assertEqual(t, spill.Count(), len(testRecords)-1)
+
+	err := spill.ForEach(func(category string, featureCollection *geojson.FeatureCollection) error {
+		wantFeatures := want[category].Features
+		assertEqual(t, len(featureCollection.Features), len(wantFeatures))
+		for i, f := range featureCollection.Features {
+			assertEqual(t, fmt.Sprint(f.Geometry.Point), fmt.Sprint(wantFeatures[i].Geometry.Point))
+			assertEqual(t, len(f.Properties), len(wantFeatures[i].Properties))
+			for key, value := range wantFeatures[i].Properties {
+				assertEqual(t, f.Properties[key], value)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSpillInterleaved(t *testing.T) {
+	spill := newTestSpill(t)
+	// flushed after every few features
+	spill.maxBuffered = 300
+
+	categories := []string{"Piran/Piran", "Ljubljana/Črnuče", "Ljubljana/Ljubljana"}
+	for i := 0; i < 30; i++ {
+		f := geojson.NewPointFeature([]float64{14 + float64(i)/100, 46})
+		f.SetProperty(tagRef, fmt.Sprint(i))
+		if err := spill.Add(categories[i%len(categories)], f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertEqual(t, spill.Count(), 30)
+	assertEqual(t, strings.Join(spill.Categories(), ","), "Ljubljana/Ljubljana,Ljubljana/Črnuče,Piran/Piran")
+
+	var read []string
+	err := spill.ForEach(func(category string, featureCollection *geojson.FeatureCollection) error {
+		var refs []string
+		for _, f := range featureCollection.Features {
+			refs = append(refs, f.Properties[tagRef].(string))
+		}
+		read = append(read, category+": "+strings.Join(refs, ","))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// categories sorted, features in the order they were added
+	assertEqual(t, strings.Join(read, "\n"), strings.Join([]string{
+		"Ljubljana/Ljubljana: 2,5,8,11,14,17,20,23,26,29",
+		"Ljubljana/Črnuče: 1,4,7,10,13,16,19,22,25,28",
+		"Piran/Piran: 0,3,6,9,12,15,18,21,24,27",
+	}, "\n"))
+
+	stop := errors.New("stop")
+	calls := 0
+	err = spill.ForEach(func(string, *geojson.FeatureCollection) error {
+		calls++
+		return stop
+	})
+	assertEqual(t, err, stop)
+	assertEqual(t, calls, 1)
+}
+
+func TestSpillClose(t *testing.T) {
+	spill, err := NewSpill(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := spill.Add("Piran/Piran", geojson.NewPointFeature([]float64{13.57, 45.53})); err != nil {
+		t.Fatal(err)
+	}
+	if err := spill.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(spill.dir); err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, spill.Close(), nil)
+	_, err = os.Stat(spill.dir)
+	assertEqual(t, errors.Is(err, fs.ErrNotExist), true)
+}
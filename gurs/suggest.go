@@ -0,0 +1,235 @@
+package gurs
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// a word next to the abbreviation in the same order as in other names counts as much as this many occurrences
+const contextWeight = 10
+
+// number of less likely expansions listed in the reason column
+const maxAlternatives = 3
+
+// Suggestion is a candidate override expanding the abbreviations of a lookup name
+type Suggestion struct {
+	Column      string
+	Original    string
+	Replacement string
+	// 0-1, product of the share of the chosen expansion among all candidates of each abbreviation
+	Confidence float64
+	// other expansions of the abbreviations with their confidence, most likely first
+	Alternatives []string
+}
+
+// wordDictionary counts full (not abbreviated) words of all names, lowercased
+type wordDictionary struct {
+	words map[string]int
+	// consecutive words in names
+	pairs map[[2]string]int
+}
+
+func newWordDictionary() *wordDictionary {
+	return &wordDictionary{words: make(map[string]int), pairs: make(map[[2]string]int)}
+}
+
+// add counts the full words of the name and pairs of them
+func (d *wordDictionary) add(name string) {
+	tokens := strings.Fields(strings.ToLower(name))
+	for i, token := range tokens {
+		if strings.Contains(token, ".") {
+			continue
+		}
+		d.words[token]++
+		if i > 0 && !strings.Contains(tokens[i-1], ".") {
+			d.pairs[[2]string{tokens[i-1], token}]++
+		}
+	}
+}
+
+type expansion struct {
+	word  string
+	score int
+}
+
+// expansions returns full words starting with the abbreviation at tokens[i], best first,
+// scored by frequency and by how often they follow the previous and precede the next word elsewhere
+func (d *wordDictionary) expansions(tokens []string, i int) []expansion {
+	prefix := strings.ToLower(strings.TrimSuffix(tokens[i], "."))
+	var previous, next string
+	if i > 0 {
+		previous = strings.ToLower(tokens[i-1])
+	}
+	if i+1 < len(tokens) {
+		next = strings.ToLower(tokens[i+1])
+	}
+
+	var candidates []expansion
+	for word, count := range d.words {
+		if len(word) <= len(prefix) || !strings.HasPrefix(word, prefix) {
+			continue
+		}
+		score := count + contextWeight*(d.pairs[[2]string{previous, word}]+d.pairs[[2]string{word, next}])
+		candidates = append(candidates, expansion{word: word, score: score})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].word < candidates[j].word
+	})
+	return candidates
+}
+
+// isAbbreviatedWord returns true for words ending with a dot which are not initials, numbers or allowed abbreviations
+func isAbbreviatedWord(token string) bool {
+	return strings.HasSuffix(token, ".") && utf8.RuneCountInString(token) > 2 && isAbbreviated(token)
+}
+
+// withCaseOf capitalizes word if abbreviation is capitalized
+func withCaseOf(word, abbreviation string) string {
+	first, _ := utf8.DecodeRuneInString(abbreviation)
+	if !unicode.IsUpper(first) {
+		return word
+	}
+	r, size := utf8.DecodeRuneInString(word)
+	return string(unicode.ToUpper(r)) + word[size:]
+}
+
+// suggest expands all abbreviated words of the name, false if the name has none or one can not be expanded
+func (d *wordDictionary) suggest(column, name string) (Suggestion, bool) {
+	suggestion := Suggestion{Column: column, Original: name, Confidence: 1}
+	tokens := strings.Fields(name)
+	expanded := make([]string, len(tokens))
+	var abbreviated bool
+	for i, token := range tokens {
+		expanded[i] = token
+		if !isAbbreviatedWord(token) {
+			continue
+		}
+		abbreviated = true
+
+		candidates := d.expansions(tokens, i)
+		if len(candidates) == 0 {
+			log.Printf("No expansion of %s found for %s: %s", token, column, name)
+			return Suggestion{}, false
+		}
+		total := 0
+		for _, candidate := range candidates {
+			total += candidate.score
+		}
+		expanded[i] = withCaseOf(candidates[0].word, token)
+		suggestion.Confidence *= float64(candidates[0].score) / float64(total)
+		for _, candidate := range candidates[1:min(len(candidates), maxAlternatives+1)] {
+			suggestion.Alternatives = append(suggestion.Alternatives,
+				fmt.Sprintf("%s %.2f", withCaseOf(candidate.word, token), float64(candidate.score)/float64(total)))
+		}
+	}
+	suggestion.Replacement = strings.Join(expanded, " ")
+	return suggestion, abbreviated
+}
+
+// SuggestOverrides expands abbreviated names of the UL, NA, OB and PT lookups not covered by overrides yet,
+// using a dictionary of the words in all their names and in override replacements;
+// sorted by column, least confident first
+func (c *Converter) SuggestOverrides() ([]Suggestion, error) {
+	dictionary := newWordDictionary()
+	names := make(map[string]map[string]bool)
+	var columns []string
+	for _, source := range c.lookupSources() {
+		if !strings.HasSuffix(source.valueCol, "_UIME") && !strings.HasSuffix(source.valueCol, "_DJ") {
+			continue
+		}
+		columns = append(columns, source.valueCol)
+
+		overrides, err := readOverrides(filepath.Join(c.overridesDir, source.valueCol+".csv"))
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range overrides.rows {
+			dictionary.add(row.Replacement)
+		}
+
+		names[source.valueCol] = make(map[string]bool)
+		err = c.readColumns(source.shapeName, source.keyCol, source.valueCol, func(key, value string) {
+			dictionary.add(value)
+			if overrides.lookup(key, value) == nil {
+				names[source.valueCol][value] = true
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var suggestions []Suggestion
+	for _, column := range columns {
+		var columnSuggestions []Suggestion
+		for name := range names[column] {
+			if suggestion, found := dictionary.suggest(column, name); found {
+				columnSuggestions = append(columnSuggestions, suggestion)
+			}
+		}
+		sort.Slice(columnSuggestions, func(i, j int) bool {
+			if columnSuggestions[i].Confidence != columnSuggestions[j].Confidence {
+				return columnSuggestions[i].Confidence < columnSuggestions[j].Confidence
+			}
+			return columnSuggestions[i].Original < columnSuggestions[j].Original
+		})
+		suggestions = append(suggestions, columnSuggestions...)
+	}
+	return suggestions, nil
+}
+
+// WriteSuggestions saves suggestions to <dir>/<COLUMN>.csv in the overrides format,
+// the confidence and alternatives go to the reason so the files can be copied to overrides as they are
+func WriteSuggestions(suggestions []Suggestion, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	byColumn := make(map[string][]Suggestion)
+	for _, suggestion := range suggestions {
+		byColumn[suggestion.Column] = append(byColumn[suggestion.Column], suggestion)
+	}
+	for column, columnSuggestions := range byColumn {
+		if err := writeSuggestionsCSV(columnSuggestions, filepath.Join(dir, column+".csv")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeSuggestionsCSV(suggestions []Suggestion, filename string) error {
+	csvFile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer csvFile.Close()
+
+	writer := csv.NewWriter(csvFile)
+	if err := writer.Write([]string{"original", "replacement", "mid", "reason"}); err != nil {
+		return err
+	}
+	for _, suggestion := range suggestions {
+		reason := fmt.Sprintf("suggested with confidence %.2f", suggestion.Confidence)
+		if len(suggestion.Alternatives) > 0 {
+			reason += ", also " + strings.Join(suggestion.Alternatives, ", ")
+		}
+		row := []string{suggestion.Original, suggestion.Replacement, "", reason}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return csvFile.Close()
+}
@@ -0,0 +1,109 @@
+package gurs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWordDictionary(t *testing.T) {
+	dictionary := newWordDictionary()
+	for _, name := range []string{
+		"Sveti Andraž v Slovenskih goricah", "Lenart v Slovenskih goricah", "Slovenska Bistrica",
+		"Slovenski trg", "Slovenske Konjice", "Velike Lašče", "Dobrova pri Velikih Laščah",
+		"Moravci v Slov. goricah", "Naselje J. Kerenčiča", "Trg 1. maja",
+	} {
+		dictionary.add(name)
+	}
+
+	suggestion, found := dictionary.suggest("NA_UIME", "Moravci v Slov. goricah")
+	assertEqual(t, found, true)
+	assertEqual(t, suggestion.Replacement, "Moravci v Slovenskih goricah")
+	// 2 + 10 * (2 + 2) of 42 + 1 + 1 + 1
+	assertBetween(t, int(suggestion.Confidence*100), 92, 94)
+	assertEqual(t, strings.Join(suggestion.Alternatives, ", "), "Slovenska 0.02, Slovenske 0.02, Slovenski 0.02")
+
+	// both abbreviations expanded
+	suggestion, found = dictionary.suggest("NA_UIME", "Črni Potok pri Vel. Laščah v Slov. goricah")
+	assertEqual(t, found, true)
+	assertEqual(t, suggestion.Replacement, "Črni Potok pri Velikih Laščah v Slovenskih goricah")
+
+	// initials, numbers and names without abbreviations
+	_, found = dictionary.suggest("UL_UIME", "Naselje J. Kerenčiča")
+	assertEqual(t, found, false)
+	_, found = dictionary.suggest("UL_UIME", "Trg 1. maja")
+	assertEqual(t, found, false)
+	_, found = dictionary.suggest("UL_UIME", "Sv. Duh")
+	assertEqual(t, found, false)
+	_, found = dictionary.suggest("UL_UIME", "Slovenska cesta")
+	assertEqual(t, found, false)
+	_, found = dictionary.suggest("UL_UIME", "Pot na Xyz.")
+	assertEqual(t, found, false)
+}
+
+func TestSuggestOverrides(t *testing.T) {
+	dataDir, overridesDir := t.TempDir(), t.TempDir()
+	writeTestData(t, dataDir, testRecords)
+
+	converter, err := NewConverter(dataDir, overridesDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer converter.Close()
+
+	suggestions, err := converter.SuggestOverrides()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, len(suggestions), 1)
+	assertEqual(t, suggestions[0].Column, "UL_UIME")
+	assertEqual(t, suggestions[0].Original, "Cesta slov. kmečkih uporov")
+	assertEqual(t, suggestions[0].Replacement, "Cesta slovenska kmečkih uporov")
+
+	outDir := filepath.Join(t.TempDir(), "suggested")
+	if err := WriteSuggestions(suggestions, outDir); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(filepath.Join(outDir, "UL_UIME.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, string(content), "original,replacement,mid,reason\nCesta slov. kmečkih uporov,Cesta slovenska kmečkih uporov,,suggested with confidence 1.00\n")
+
+	// ready to be copied to overrides
+	o, err := readOverrides(filepath.Join(outDir, "UL_UIME.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, o.apply("103", "Cesta slov. kmečkih uporov"), "Cesta slovenska kmečkih uporov")
+
+	// covered by an override
+	writeTestOverrides(t, overridesDir, "UL_UIME", "Cesta slov. kmečkih uporov,Cesta slovenskih kmečkih uporov\n")
+	suggestions, err = converter.SuggestOverrides()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, len(suggestions), 0)
+}
+
+func TestWriteSuggestionsReadBack(t *testing.T) {
+	dir := t.TempDir()
+	err := WriteSuggestions([]Suggestion{{
+		Column: "NA_UIME", Original: "Moravci v Slov. goricah", Replacement: "Moravci v Slovenskih goricah",
+		Confidence: 0.75, Alternatives: []string{"Slovenskem 0.25"},
+	}}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o, err := readOverrides(filepath.Join(dir, "NA_UIME.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, len(o.rows), 1)
+	assertEqual(t, o.rows[0].Original, "Moravci v Slov. goricah")
+	assertEqual(t, o.rows[0].Replacement, "Moravci v Slovenskih goricah")
+	assertEqual(t, o.rows[0].Mid, "")
+	assertEqual(t, o.rows[0].Reason, "suggested with confidence 0.75, also Slovenskem 0.25")
+}
@@ -0,0 +1,182 @@
+package gurs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	geojson "github.com/paulmach/go.geojson"
+)
+
+// DefaultTaginfoFile is the taginfo project file listed at https://taginfo.openstreetmap.org/projects/slovenia_address_import
+const DefaultTaginfoFile = "taginfo.json"
+
+// Taginfo is a taginfo project file, see https://wiki.openstreetmap.org/wiki/Taginfo/Projects
+type Taginfo struct {
+	DataFormat  int            `json:"data_format"`
+	DataURL     string         `json:"data_url"`
+	DataUpdated string         `json:"data_updated"`
+	Project     TaginfoProject `json:"project"`
+	Tags        []TaginfoTag   `json:"tags"`
+}
+
+// TaginfoProject describes the project on taginfo
+type TaginfoProject struct {
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	ProjectURL   string `json:"project_url"`
+	DocURL       string `json:"doc_url"`
+	IconURL      string `json:"icon_url"`
+	ContactName  string `json:"contact_name"`
+	ContactEmail string `json:"contact_email"`
+}
+
+// TaginfoTag is a key (or a key with a fixed value) used by the project
+type TaginfoTag struct {
+	Key         string   `json:"key"`
+	Value       string   `json:"value,omitempty"`
+	ObjectTypes []string `json:"object_types"`
+	Description string   `json:"description"`
+}
+
+// DefaultTaginfoProject is the project section of taginfo.json
+var DefaultTaginfoProject = TaginfoProject{
+	Name:         "Slovenia Address Import (GURS)",
+	Description:  "Describes tags used in the Slovenia Address Import project.",
+	ProjectURL:   "https://github.com/openstreetmap-si/GursAddressesForOSM",
+	DocURL:       "https://wiki.openstreetmap.org/wiki/Slovenia_Address_Import",
+	IconURL:      "https://raw.githubusercontent.com/openstreetmap-si/GursAddressesForOSM/master/GURS-logo.png",
+	ContactName:  "Štefan Baebler",
+	ContactEmail: "stefan.baebler X gmail",
+}
+
+const taginfoDataURL = "https://raw.githubusercontent.com/openstreetmap-si/GursAddressesForOSM/master/taginfo.json"
+
+// addresses can be conflated to nodes, building outlines and multipolygons
+var taginfoObjectTypes = []string{"node", "area", "relation"}
+
+// taginfoDescriptions of the keys by their name in TagKeys, with language postfixes for bilingual names
+var taginfoDescriptions = map[string]string{
+	"housenumber": "House number, with lowercase suffixes",
+	"street":      "Street name (both names in bilingual regions, separated by '/')",
+	"street:sl":   "Slovenian-only street name (only in bilingual regions)",
+	"street:it":   "Italian street name (only in bilingual South-West part of Slovenia)",
+	"street:hu":   "Hungarian street name (only in bilingual North-East part of Slovenia)",
+	"place":       "Place name of addresses without a street (both names in bilingual regions, separated by '/')",
+	"place:sl":    "Slovenian-only place name (only in bilingual regions)",
+	"place:it":    "Italian place name (only in bilingual South-West part of Slovenia)",
+	"place:hu":    "Hungarian place name (only in bilingual North-East part of Slovenia)",
+	"village":     "Place name if not in the street or postal area name (both names in bilingual regions, separated by '/')",
+	"village:sl":  "Slovenian-only place name (only in bilingual regions)",
+	"village:it":  "Italian place name (only in bilingual South-West part of Slovenia)",
+	"village:hu":  "Hungarian place name (only in bilingual North-East part of Slovenia)",
+	"postcode":    "4-digit Slovenian post code",
+	"city":        "Postal area name (both names in bilingual regions, separated by '/')",
+	"city:sl":     "Slovenian-only postal area name (only in bilingual regions)",
+	"city:it":     "Italian postal area name (only in bilingual South-West part of Slovenia)",
+	"city:hu":     "Hungarian postal area name (only in bilingual North-East part of Slovenia)",
+	"source":      "The address was obtained from The Surveying and Mapping Authority of Republic of Slovenia (GURS)",
+	"source_date": "Date of validity in GURS source database",
+	"ref":         "Preserving numeric address id from GURS source database for future updates",
+}
+
+// taginfoBuildingTags of building outlines are listed in the project, the converter does not produce them
+var taginfoBuildingTags = []TaginfoTag{
+	{Key: "source:geometry", Value: "GURS", ObjectTypes: []string{"area", "relation"},
+		Description: "The geometry was obtained from The Surveying and Mapping Authority of Republic of Slovenia (GURS)"},
+	{Key: "ref:gurs:sta_sid", ObjectTypes: []string{"area", "relation"},
+		Description: "Preserving numeric building id from GURS source database for future updates"},
+}
+
+// profileKey is an OSM key the profile can produce with its name in TagKeys (and language postfix)
+type profileKey struct {
+	name, key string
+}
+
+// producedKeys returns the keys of the enabled values in TagKeys order, bilingual names followed by their
+// language keys with Languages
+func (p *TaggingProfile) producedKeys() []profileKey {
+	bilingual := map[string]bool{"street": true, "place": true, "village": true, "city": true}
+	keys := p.Keys.byName()
+
+	var produced []profileKey
+	for _, name := range tagKeyNames {
+		if keys[name] == "" {
+			continue
+		}
+		produced = append(produced, profileKey{name: name, key: keys[name]})
+		if !p.Languages || !bilingual[name] {
+			continue
+		}
+		for _, postfix := range []string{tagLangPostfixSlovenian, tagLangPostfixItalian, tagLangPostfixHungarian} {
+			produced = append(produced, profileKey{name: name + postfix, key: keys[name] + postfix})
+		}
+	}
+	return produced
+}
+
+// TaginfoExamples is a FeatureSink keeping the first value of every key in the converted data
+type TaginfoExamples map[string]string
+
+func (examples TaginfoExamples) Add(category string, f *geojson.Feature) error {
+	for key, value := range f.Properties {
+		if _, found := examples[key]; !found {
+			examples[key] = fmt.Sprint(value)
+		}
+	}
+	return nil
+}
+
+// NewTaginfo lists the keys the profile can produce with their descriptions and the example values,
+// keys in the examples which the profile does not describe are an error
+func NewTaginfo(profile *TaggingProfile, examples TaginfoExamples, updated time.Time) (*Taginfo, error) {
+	taginfo := &Taginfo{
+		DataFormat:  1,
+		DataURL:     taginfoDataURL,
+		DataUpdated: updated.UTC().Format("20060102T150405Z"),
+		Project:     DefaultTaginfoProject,
+	}
+
+	described := make(map[string]bool)
+	for _, produced := range profile.producedKeys() {
+		description := taginfoDescriptions[produced.name]
+		if description == "" {
+			return nil, fmt.Errorf("no taginfo description of %s (%s)", produced.key, produced.name)
+		}
+		tag := TaginfoTag{Key: produced.key, ObjectTypes: taginfoObjectTypes, Description: description}
+		switch example, found := examples[produced.key]; {
+		case produced.name == "source":
+			tag.Value = profile.SourceValue
+		case found:
+			tag.Description += ", eg. " + example
+		}
+		taginfo.Tags = append(taginfo.Tags, tag)
+		described[produced.key] = true
+	}
+	taginfo.Tags = append(taginfo.Tags, taginfoBuildingTags...)
+
+	var undescribed []string
+	for key := range examples {
+		if !described[key] {
+			undescribed = append(undescribed, key)
+		}
+	}
+	if len(undescribed) > 0 {
+		sort.Strings(undescribed)
+		return nil, fmt.Errorf("keys not in the tagging profile have no taginfo description: %s", strings.Join(undescribed, ", "))
+	}
+	return taginfo, nil
+}
+
+// Write saves the taginfo project file as indented JSON
+func (taginfo *Taginfo) Write(filename string) error {
+	rawJSON, err := json.MarshalIndent(taginfo, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(rawJSON, '\n'), fs.FileMode(0644))
+}
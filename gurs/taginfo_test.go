@@ -0,0 +1,152 @@
+package gurs
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// taginfoTestExamples converts the test records into examples of every emitted key
+func taginfoTestExamples(t *testing.T) TaginfoExamples {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeTestData(t, dir, testRecords)
+	converter := newTestConverter(t, dir)
+	examples := TaginfoExamples{}
+	if err := converter.Convert(openTestHS(t, converter), examples); err != nil {
+		t.Fatal(err)
+	}
+	return examples
+}
+
+func TestTaginfo(t *testing.T) {
+	examples := taginfoTestExamples(t)
+	// fails when the converter emits a key without a description in taginfoDescriptions
+	taginfo, err := NewTaginfo(DefaultTaggingProfile(), examples, time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, taginfo.DataUpdated, "20240506T070809Z")
+
+	tags := make(map[string]TaginfoTag)
+	for _, tag := range taginfo.Tags {
+		tags[tag.Key] = tag
+	}
+	for key := range examples {
+		if tags[key].Description == "" {
+			t.Errorf("%s is not described", key)
+		}
+	}
+	assertEqual(t, tags[tagSource].Value, "GURS")
+	assertEqual(t, tags[tagStreet+":it"].Description,
+		"Italian street name (only in bilingual South-West part of Slovenia), eg. Piazza Giuseppe Tartini")
+	assertEqual(t, tags[tagStreet+":hu"].Description, taginfoDescriptions["street:hu"])
+	assertEqual(t, strings.Join(tags["ref:gurs:sta_sid"].ObjectTypes, ","), "area,relation")
+
+	examples["addr:country"] = "SI"
+	_, err = NewTaginfo(DefaultTaggingProfile(), examples, time.Now())
+	assertEqual(t, err.Error(), "keys not in the tagging profile have no taginfo description: addr:country")
+}
+
+func TestTaginfoProfile(t *testing.T) {
+	profile := DefaultTaggingProfile()
+	profile.Languages = false
+	profile.Keys.Place = ""
+	profile.Keys.Ref = "ref:hs"
+	taginfo, err := NewTaginfo(profile, TaginfoExamples{"ref:hs": "1001"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, tag := range taginfo.Tags {
+		keys = append(keys, tag.Key)
+	}
+	assertEqual(t, strings.Join(keys, ","),
+		"addr:housenumber,addr:street,addr:village,addr:postcode,addr:city,source:addr,source:addr:date,ref:hs,source:geometry,ref:gurs:sta_sid")
+	assertEqual(t, taginfo.Tags[7].Description, taginfoDescriptions["ref"]+", eg. 1001")
+}
+
+// readTaginfoFile reads the committed taginfo.json and the tagging profile it was generated from
+func readTaginfoFile(t *testing.T) (*Taginfo, []byte, *TaggingProfile) {
+	t.Helper()
+
+	profile, err := ReadTaggingProfile(filepath.Join("..", DefaultTaggingProfileFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawJSON, err := os.ReadFile(filepath.Join("..", DefaultTaginfoFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var taginfo Taginfo
+	if err := json.Unmarshal(rawJSON, &taginfo); err != nil {
+		t.Fatal(err)
+	}
+	return &taginfo, rawJSON, profile
+}
+
+// the committed taginfo.json is written by NewTaginfo for the committed tagging profile, examples excepted
+func TestTaginfoFile(t *testing.T) {
+	got, _, profile := readTaginfoFile(t)
+	updated, err := time.Parse("20060102T150405Z", got.DataUpdated)
+	if err != nil {
+		t.Fatalf("data_updated: %v", err)
+	}
+	want, err := NewTaginfo(profile, nil, updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, got.DataFormat, want.DataFormat)
+	assertEqual(t, got.DataURL, want.DataURL)
+	assertEqual(t, got.Project, want.Project)
+	assertEqual(t, len(got.Tags), len(want.Tags))
+	for i := range want.Tags {
+		if i >= len(got.Tags) {
+			break
+		}
+		assertEqual(t, got.Tags[i].Key, want.Tags[i].Key)
+		assertEqual(t, got.Tags[i].Value, want.Tags[i].Value)
+		assertEqual(t, strings.Join(got.Tags[i].ObjectTypes, ","), strings.Join(want.Tags[i].ObjectTypes, ","))
+		example, hasExample := strings.CutPrefix(got.Tags[i].Description, want.Tags[i].Description+", eg. ")
+		if got.Tags[i].Description != want.Tags[i].Description && (!hasExample || example == "") {
+			t.Errorf("%s: description %q should be %q with an optional example", got.Tags[i].Key, got.Tags[i].Description, want.Tags[i].Description)
+		}
+	}
+}
+
+// the committed taginfo.json is the one go run . taginfo saves for the downloaded release,
+// with its examples and date
+func TestTaginfoFileRelease(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+	_, rawJSON, profile := readTaginfoFile(t)
+
+	converter := newTestConverter(t, testDataDir)
+	converter.Profile = profile
+	hs := openTestHS(t, converter)
+	examples := TaginfoExamples{}
+	if err := converter.Convert(hs, examples); err != nil {
+		t.Fatal(err)
+	}
+	taginfo, err := NewTaginfo(profile, examples, hs.Modified)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), DefaultTaginfoFile)
+	if err := taginfo.Write(filename); err != nil {
+		t.Fatal(err)
+	}
+	generated, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(generated) != string(rawJSON) {
+		t.Errorf("%s differs from the one generated from %s, run make taginfo", DefaultTaginfoFile, testDataDir)
+	}
+}
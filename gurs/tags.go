@@ -0,0 +1,38 @@
+package gurs
+
+// OpenStreetMap tags, keys of DefaultTaggingProfile:
+const (
+	tagHousenumber = "addr:housenumber"
+	tagCity        = "addr:city"
+	tagPostCode    = "addr:postcode"
+	tagStreet      = "addr:street"
+	tagPlace       = "addr:place"
+	tagVillage     = "addr:village"
+	tagSourceDate  = "source:addr:date"
+	tagSource      = "source:addr"
+	tagSourceValue = "GURS"
+
+	// could be either "source:addr:ref", "source:ref", "ref:GURS:HS_MID", see TaggingProfile
+	tagRef = "ref:gurs:hs_mid"
+
+	tagLangPostfixSlovenian = ":sl"
+	tagLangPostfixItalian   = ":it"
+	tagLangPostfixHungarian = ":hu"
+	bilingualSeparator      = " / "
+)
+
+// ApplyTagLanguagePostfix applies language postfix to the given prefix based on longitude,
+// only a guess for bilingual names outside of the declared bilingual areas (see BilingualAreasFile)
+func ApplyTagLanguagePostfix(prefix string, longitude float64) string {
+
+	// Bilingual names with longitude greater than (right, east of this meridian) are considered in Hungarian, otherwise in Italian
+	const ItalianHungarianSplitLongitude = 14.5
+
+	if longitude > ItalianHungarianSplitLongitude {
+		// assume Hungarian
+		return prefix + tagLangPostfixHungarian
+	}
+
+	// assume Italian
+	return prefix + tagLangPostfixItalian
+}
@@ -0,0 +1,20 @@
+package gurs
+
+import (
+	"testing"
+)
+
+func TestApplyTagLanguagePostfix(t *testing.T) {
+	assertEqual(t, ApplyTagLanguagePostfix("", 0), ":it")
+	assertEqual(t, ApplyTagLanguagePostfix("a", 20), "a:hu")
+	assertEqual(t, ApplyTagLanguagePostfix("ŠomethingUTF", 14.9), "ŠomethingUTF:hu")
+	assertEqual(t, ApplyTagLanguagePostfix("realistic:tag", 14.2), "realistic:tag:it")
+
+}
+
+func BenchmarkApplyTagLanguagePostfix(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		ApplyTagLanguagePostfix("something", 14.1)
+	}
+}
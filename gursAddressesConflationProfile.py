@@ -45,9 +45,14 @@ query = [('addr:housenumber',)]
 # italia
 #bbox = [35.28,6.62,47.1,18.79]
 
-# tags to replace on matched OSM objects
-#master_tags = ('addr:housenumber', 'addr:street')
-master_tags = ('addr:housenumber', 'addr:street', 'addr:postcode', 'addr:village', 'addr:city' )
+# tags to replace on matched OSM objects: master_tags of taggingProfile.json, the same as the Go conflate command
+# (run from the repository root; conflate runs the profile with exec, so a loop instead of a comprehension)
+import json
+with open('taggingProfile.json') as tagging_profile_file:
+    tagging_profile = json.load(tagging_profile_file)
+master_tags = ()
+for master_key in tagging_profile['master_tags']:
+    master_tags += (tagging_profile['keys'][master_key],)
 
 # delete_unmatched = True cancellerebbe anche i POI con indirizzo
 delete_unmatched = False
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/openstreetmap-si/GursAddressesForOSM/gurs"
+)
+
+// runSuggestOverrides writes candidate expansions of abbreviated lookup names for review
+func runSuggestOverrides(args []string) error {
+	flags := flag.NewFlagSet("suggest-overrides", flag.ExitOnError)
+	source := flags.String("source", "data/downloaded/", "Directory with downloaded RPE_*.ZIP archives, or with extracted <NAME>/<NAME>.shp shapefiles")
+	overrides := flags.String("overrides", "overrides/", "Directory with <COLUMN>.csv overrides, names already covered are skipped")
+	out := flags.String("out", "data/suggested-overrides/", "Directory to save <COLUMN>.csv suggestions to, in the overrides format with a confidence column")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	converter, err := gurs.NewConverter(*source, *overrides)
+	if err != nil {
+		return err
+	}
+	defer converter.Close()
+
+	suggestions, err := converter.SuggestOverrides()
+	if err != nil {
+		return err
+	}
+	if err := gurs.WriteSuggestions(suggestions, *out); err != nil {
+		return err
+	}
+	log.Printf("Saved %d suggested overrides to %s, review them and copy the correct ones to %s", len(suggestions), *out, *overrides)
+	return nil
+}
@@ -227,7 +227,7 @@ cat << EOF > "$MUNOUT"
 <th>%Done</th>
 <th class="d-none d-xl-table-cell">Preview</th>
 <th class="d-none d-sm-table-cell"></th>
-<th class="d-none d-xl-table-cell">.osm</th>
+<th class="d-none d-xl-table-cell">.osc</th>
 <th class="d-none d-lg-table-cell">JOSM</th>
 </tr>
 </thead>
@@ -283,7 +283,7 @@ if [ ! -f "$DIRNAME/$BASENAME-conflate-log.txt" ]; then
 # <th>%Done</th>
 # <th class="d-none d-xl-table-cell">Preview</th>
 # <th class="d-none d-sm-table-cell"></th>
-# <th class="d-none d-xl-table-cell">.osm</th>
+# <th class="d-none d-xl-table-cell">.osc</th>
 # <th class="d-none d-lg-table-cell">JOSM</th>
         continue
 fi
@@ -384,11 +384,11 @@ fi
 	PREVIEWGJIO="<a href='https://geojson.io/#data=data:text/x-url,https%3A%2F%2Faddr.openstreetmap.si%2F$MUNDIRURL%2F$BASENAMEURL-preview.geojson'>🌍</a>"
 	echo "<td class=\"d-none d-sm-table-cell\">$PREVIEWGJIO</td>" >> "$MUNOUT"
 
-	OSMLINK="<a href='$BASENAME.osm'>.osm</a>"
+	OSMLINK="<a href='$BASENAME.osc'>.osc</a>"
 	echo "<td class=\"d-none d-xl-table-cell\">$OSMLINK</td>" >> "$MUNOUT"
 
 	# JOSM import - https://wiki.openstreetmap.org/wiki/JOSM/RemoteControl#import_command
-	JOSMIMPORT="<a href='http://localhost:8111/import?url=https%3A%2F%2Faddr.openstreetmap.si%2F$MUNDIRURL%2F$BASENAMEURL.osm' onclick='javascript:josmImport(this);return false;'>Load</a>"
+	JOSMIMPORT="<a href='http://localhost:8111/import?url=https%3A%2F%2Faddr.openstreetmap.si%2F$MUNDIRURL%2F$BASENAMEURL.osc' onclick='javascript:josmImport(this);return false;'>Load</a>"
 	echo "<td class=\"d-none d-lg-table-cell\">$JOSMIMPORT</td>" >> "$MUNOUT"
 
 	echo "</tr>" >> "$MUNOUT"
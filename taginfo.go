@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/openstreetmap-si/GursAddressesForOSM/gurs"
+)
+
+// runTaginfo converts the HS records only to collect example values and saves the taginfo project file
+func runTaginfo(args []string) error {
+	flags := flag.NewFlagSet("taginfo", flag.ExitOnError)
+	source := flags.String("source", "data/downloaded/", "Directory with downloaded RPE_*.ZIP archives, or with extracted <NAME>/<NAME>.shp shapefiles")
+	overrides := flags.String("overrides", "overrides/", "Directory with <COLUMN>.csv overrides")
+	profileFileName := flags.String("profile", gurs.DefaultTaggingProfileFile, "JSON tagging profile with the keys to list")
+	in := flags.String("in", "", "Input HS shapefile, read from the -source if empty")
+	out := flags.String("out", gurs.DefaultTaginfoFile, "Taginfo project file to save")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	converter, err := gurs.NewConverter(*source, *overrides)
+	if err != nil {
+		return err
+	}
+	defer converter.Close()
+
+	if converter.Profile, err = gurs.ReadTaggingProfile(*profileFileName); err != nil {
+		return err
+	}
+	if err := converter.ReadLookups(); err != nil {
+		return err
+	}
+
+	hs, err := converter.OpenHS(*in)
+	if err != nil {
+		return err
+	}
+	defer hs.Close()
+	log.Printf("Reading %s...", hs.Name)
+
+	examples := gurs.TaginfoExamples{}
+	if err := converter.Convert(hs, examples); err != nil {
+		return err
+	}
+	taginfo, err := gurs.NewTaginfo(converter.Profile, examples, hs.Modified)
+	if err != nil {
+		return err
+	}
+	if err := taginfo.Write(*out); err != nil {
+		return err
+	}
+	log.Printf("Saved %d tags to %s", len(taginfo.Tags), *out)
+	return nil
+}